@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+
+	"hmcalister/hopfield/hopfieldnetwork/datacollector"
+)
+
+const ANALYSIS_SUMMARY_SAVE_FILE = "analysisSummary.pq"
+
+// Summarize the data files written by the run or probe command, from the given command line arguments.
+//
+// The summary is printed and written to the run directory. Data files that are missing (e.g. uniqueStates.pq if
+// that data was not collected) are skipped, but the relaxation results must be present. Only runs written in the
+// parquet output format can be analyzed.
+func runAnalyzeCommand(args []string) error {
+	analyzeFlags := flag.NewFlagSet("analyze", flag.ExitOnError)
+	runDirectory := analyzeFlags.String("runDir", "", "The run directory to analyze, as written by the run or probe command. Required.")
+	analyzeFlags.Parse(args)
+
+	if *runDirectory == "" {
+		return errors.New("runDir must be given")
+	}
+
+	// The data files are read as parquet, so runs written in another format can not be analyzed
+	config := defaultExperimentConfig()
+	if err := config.LoadFile(path.Join(*runDirectory, EXPERIMENT_CONFIG_SAVE_FILE)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("run configuration loading failed: %w", err)
+	}
+	if config.DataCollection.OutputFormat != datacollector.ParquetFormat {
+		return fmt.Errorf("run directory %#v was written in %v, only %v runs can be analyzed", *runDirectory, config.DataCollection.OutputFormat, datacollector.ParquetFormat)
+	}
+
+	summary := &datacollector.AnalysisSummaryData{}
+
+	relaxationResults, err := datacollector.ReadRelaxationResults(path.Join(*runDirectory, RELAXATION_RESULT_SAVE_FILE))
+	if err != nil {
+		return fmt.Errorf("relaxation result loading failed: %w", err)
+	}
+	totalSteps := 0
+	for _, result := range relaxationResults {
+		summary.ProbeStates += 1
+		totalSteps += result.NumSteps
+		if result.Stable {
+			summary.StableProbeStates += 1
+		}
+		if result.ExactRecall {
+			summary.ProbesRelaxedToTarget += 1
+		}
+	}
+	if summary.ProbeStates > 0 {
+		summary.MeanRelaxationSteps = float64(totalSteps) / float64(summary.ProbeStates)
+	}
+
+	targetStateProbes, err := datacollector.ReadTargetStateProbes(path.Join(*runDirectory, TARGET_STATE_PROBE_SAVE_FILE))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("target state probe loading failed: %w", err)
+	}
+	for _, targetStateProbe := range targetStateProbes {
+		summary.TargetStates += 1
+		if targetStateProbe.IsStable {
+			summary.StableTargetStates += 1
+		}
+	}
+
+	uniqueRelaxedStates, err := datacollector.ReadUniqueRelaxedStates(path.Join(*runDirectory, UNIQUE_STATES_SAVE_FILE))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("unique relaxed states loading failed: %w", err)
+	}
+	summary.UniqueRelaxedStates = len(uniqueRelaxedStates)
+
+	fmt.Fprintf(os.Stdout, "Analysis of %v\n", *runDirectory)
+	fmt.Fprintf(os.Stdout, "\tStable Target States:     %v / %v\n", summary.StableTargetStates, summary.TargetStates)
+	fmt.Fprintf(os.Stdout, "\tStable Probe States:      %v / %v\n", summary.StableProbeStates, summary.ProbeStates)
+	fmt.Fprintf(os.Stdout, "\tProbes Relaxed To Target: %v / %v\n", summary.ProbesRelaxedToTarget, summary.ProbeStates)
+	fmt.Fprintf(os.Stdout, "\tMean Relaxation Steps:    %.3f\n", summary.MeanRelaxationSteps)
+	fmt.Fprintf(os.Stdout, "\tUnique Relaxed States:    %v\n", summary.UniqueRelaxedStates)
+
+	if err := datacollector.WriteAnalysisSummary(path.Join(*runDirectory, ANALYSIS_SUMMARY_SAVE_FILE), summary); err != nil {
+		return err
+	}
+	// The summary is a new file of the run, so must be listed in the manifest
+	return refreshManifest(*runDirectory)
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path"
+
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+
+	"hmcalister/hopfield/hopfieldnetwork/datacollector"
+	"hmcalister/hopfield/hopfieldnetwork/domain"
+	"hmcalister/hopfield/hopfieldnetwork/noiseapplication"
+)
+
+const (
+	BASIN_PROFILE_SAVE_FILE = "basinProfile.pq"
+	BASIN_RADIUS_SAVE_FILE  = "basinRadius.pq"
+)
+
+// The settings of a basin of attraction estimate, recorded in the manifest alongside the network configuration.
+type BasinConfig struct {
+	NetworkDirectory      string  `json:"networkDirectory"`
+	ProbesPerDistance     int     `json:"probesPerDistance"`
+	MaximumDistance       int     `json:"maximumDistance"`
+	DistanceStep          int     `json:"distanceStep"`
+	RecallThreshold       float64 `json:"recallThreshold"`
+	SaveRelaxationResults bool    `json:"saveRelaxationResults"`
+}
+
+// Estimate the basin of attraction of each target state of a saved network, from the given command line arguments.
+//
+// For each target state, probes are generated by flipping a fixed number of units (the Hamming distance) of the target
+// state using noiseapplication.MaximalInversion, and relaxed with ConcurrentRelaxStatesFromTargets. The flipped units are
+// selected by a generator seeded separately from the network (seed + 2, as the state generator uses seed + 1), so the flips
+// are independent of the order units are updated in. The basin radius of a target
+// state is the largest distance such that at least the recall threshold fraction of probes relax exactly onto the target
+// state, at that distance and every smaller distance probed.
+//
+// The recall at every distance is written to basinProfile.pq and the radius of each target state to basinRadius.pq.
+// If requested, the result of every probe (including the recall of the target state it was generated from) is written to relaxationResult.pq.
+func runBasinCommand(args []string) error {
+	basinFlags := flag.NewFlagSet("basin", flag.ExitOnError)
+	networkDirectory := basinFlags.String("networkDir", "", "The run directory of the saved network, as written by the train or run command. Required.")
+	seed := basinFlags.Uint64("seed", 0, "The seed for the random generators of the network and probes. Defaults to the seed of the saved network.")
+	numThreads := basinFlags.Int("threads", 1, "The number of threads to use for relaxation. Defaults to the value of the saved network.")
+	probesPerDistance := basinFlags.Int("probesPerDistance", 100, "The number of probes generated for each target state at each Hamming distance.")
+	maximumDistance := basinFlags.Int("maximumDistance", 0, "The largest Hamming distance to probe. If 0, half the network dimension is used, beyond which probes are closer to the inverse target state.")
+	distanceStep := basinFlags.Int("distanceStep", 1, "The step between the Hamming distances probed.")
+	recallThreshold := basinFlags.Float64("recallThreshold", 0.9, "The fraction of probes at a distance that must relax exactly onto the target state for that distance to be within the basin.")
+	saveRelaxationResults := basinFlags.Bool("saveRelaxationResults", false, "Write the result of relaxing every probe to relaxationResult.pq, including the units flipped from the target state the probe was generated from.")
+	dataFileFlags := addDataFileFlags(basinFlags, defaultExperimentConfig().DataCollection, "\nDefaults to the value of the saved network.")
+	outputFlags := addOutputFlags(basinFlags, "data/hopfieldBasin")
+	basinFlags.Parse(args)
+
+	if *networkDirectory == "" {
+		return errors.New("networkDir must be given")
+	}
+
+	config, matrix, targetStates, _, err := loadSavedNetwork(*networkDirectory)
+	if err != nil {
+		return err
+	}
+	// The probes of this command are at most written as relaxation results, so no other data is collected by the network
+	config.DataCollection.RelaxationHistory = false
+	basinFlags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "seed":
+			config.Seed = *seed
+		case "threads":
+			config.Probing.Threads = *numThreads
+		}
+	})
+	dataFileFlags.applyTo(&config.DataCollection, true)
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	basinConfig := BasinConfig{
+		NetworkDirectory:      *networkDirectory,
+		ProbesPerDistance:     *probesPerDistance,
+		MaximumDistance:       *maximumDistance,
+		DistanceStep:          *distanceStep,
+		RecallThreshold:       *recallThreshold,
+		SaveRelaxationResults: *saveRelaxationResults,
+	}
+	if basinConfig.MaximumDistance == 0 {
+		basinConfig.MaximumDistance = config.Network.Dimension / 2
+	}
+	if basinConfig.ProbesPerDistance <= 0 {
+		return fmt.Errorf("probesPerDistance must be a positive integer, got %d", basinConfig.ProbesPerDistance)
+	}
+	// MaximalInversion never flips the last unit, so at most dimension-1 units can be flipped
+	if basinConfig.MaximumDistance < 0 || basinConfig.MaximumDistance >= config.Network.Dimension {
+		return fmt.Errorf("maximumDistance must be in the range [0, %d], got %d", config.Network.Dimension-1, basinConfig.MaximumDistance)
+	}
+	if basinConfig.DistanceStep <= 0 {
+		return fmt.Errorf("distanceStep must be a positive integer, got %d", basinConfig.DistanceStep)
+	}
+	if basinConfig.RecallThreshold < 0.0 || basinConfig.RecallThreshold > 1.0 {
+		return fmt.Errorf("recallThreshold must be in the range [0.0, 1.0], got %v", basinConfig.RecallThreshold)
+	}
+
+	defer startProfiling(*outputFlags.enableProfiling)()
+
+	runDirectory, err := outputFlags.createRunDirectory()
+	if err != nil {
+		return err
+	}
+	logger, err := outputFlags.newLogger(runDirectory)
+	if err != nil {
+		return fmt.Errorf("could not open log file: %w", err)
+	}
+	logger.Printf("Writing to run directory %#v\n", runDirectory)
+
+	collector := datacollector.NewBufferedDataCollector(DATA_COLLECTOR_BUFFER_SIZE)
+	if basinConfig.SaveRelaxationResults {
+		relaxationResultFile := path.Join(runDirectory, dataFileName(RELAXATION_RESULT_SAVE_FILE, config.DataCollection.OutputFormat))
+		handler, err := datacollector.NewRelaxationResultHandler(relaxationResultFile, config.DataCollection.DataFileSettings())
+		if err != nil {
+			return fmt.Errorf("data handler creation failed: %w", err)
+		}
+		collector.AddHandler(handler)
+	}
+	collector.Start()
+	defer collector.Close()
+
+	network := newNetworkBuilder(config, collector, logger).
+		SetMatrix(matrix).
+		SetTargetStates(targetStates).
+		Build()
+
+	// GENERATE & RELAX PROBES --------------------------------------------------------------------
+	logger.SetPrefix("Basin Estimation: ")
+	distances := []int{}
+	for distance := 0; distance <= basinConfig.MaximumDistance; distance += basinConfig.DistanceStep {
+		distances = append(distances, distance)
+	}
+
+	// The probe flips are seeded separately from the network (and state generator) so the random streams are independent
+	flipSeed := config.Seed
+	if flipSeed != 0 {
+		flipSeed += 2
+	}
+	randomGenerator := rand.New(rand.NewSource(flipSeed))
+	probes := make([]*mat.VecDense, 0, len(targetStates)*len(distances)*basinConfig.ProbesPerDistance)
+	originTargetIndices := make([]int, 0, cap(probes))
+	for targetIndex, targetState := range targetStates {
+		for _, distance := range distances {
+			for probeIndex := 0; probeIndex < basinConfig.ProbesPerDistance; probeIndex++ {
+				probes = append(probes, flipTargetStateUnits(randomGenerator, config.Network.Domain, targetState, distance))
+				originTargetIndices = append(originTargetIndices, targetIndex)
+			}
+		}
+	}
+	logger.Printf("Relaxing %v probes (%v target states, %v distances)\n", len(probes), len(targetStates), len(distances))
+	results := network.ConcurrentRelaxStatesFromTargets(probes, originTargetIndices, config.Probing.Threads)
+
+	// MEASURE RECALL -----------------------------------------------------------------------------
+	profileData := []*datacollector.BasinProfileData{}
+	radiusData := []*datacollector.BasinRadiusData{}
+	for targetIndex := range targetStates {
+		radius := -1
+		withinBasin := true
+		for distanceIndex, distance := range distances {
+			profile := &datacollector.BasinProfileData{
+				TargetIndex: targetIndex,
+				Distance:    distance,
+			}
+			totalSteps := 0
+			resultsOffset := (targetIndex*len(distances) + distanceIndex) * basinConfig.ProbesPerDistance
+			for _, result := range results[resultsOffset : resultsOffset+basinConfig.ProbesPerDistance] {
+				profile.Probes += 1
+				totalSteps += result.NumSteps
+				if result.Stable {
+					profile.StableProbes += 1
+				}
+				// Every unit of the inverse of a target state differs from the target state
+				if result.FlippedUnits == 0 {
+					profile.CorrectRecalls += 1
+				} else if result.FlippedUnits == config.Network.Dimension {
+					profile.InverseRecalls += 1
+				}
+			}
+			profile.RecallFraction = float64(profile.CorrectRecalls) / float64(profile.Probes)
+			profile.MeanRelaxationSteps = float64(totalSteps) / float64(profile.Probes)
+			profileData = append(profileData, profile)
+
+			withinBasin = withinBasin && profile.RecallFraction >= basinConfig.RecallThreshold
+			if withinBasin {
+				radius = distance
+			}
+		}
+
+		logger.Printf("Target state %v has basin radius %v\n", targetIndex, radius)
+		radiusData = append(radiusData, &datacollector.BasinRadiusData{
+			TargetIndex:     targetIndex,
+			BasinRadius:     radius,
+			RecallThreshold: basinConfig.RecallThreshold,
+			MaximumDistance: distances[len(distances)-1],
+		})
+	}
+
+	// CLEAN UP & FINISH --------------------------------------------------------------------------
+	logger.SetPrefix("Clean Up: ")
+	outputFormat := config.DataCollection.OutputFormat
+	if err := datacollector.WriteBasinProfiles(path.Join(runDirectory, dataFileName(BASIN_PROFILE_SAVE_FILE, outputFormat)), config.DataCollection.DataFileSettings(), profileData); err != nil {
+		return fmt.Errorf("basin profile saving failed: %w", err)
+	}
+	if err := datacollector.WriteBasinRadii(path.Join(runDirectory, dataFileName(BASIN_RADIUS_SAVE_FILE, outputFormat)), config.DataCollection.DataFileSettings(), radiusData); err != nil {
+		return fmt.Errorf("basin radius saving failed: %w", err)
+	}
+	if err := config.WriteFile(path.Join(runDirectory, EXPERIMENT_CONFIG_SAVE_FILE)); err != nil {
+		return fmt.Errorf("configuration saving failed: %w", err)
+	}
+	if err := collector.Close(); err != nil {
+		return err
+	}
+
+	manifestConfiguration := struct {
+		Experiment *ExperimentConfig `json:"experiment"`
+		Basin      BasinConfig       `json:"basin"`
+	}{config, basinConfig}
+	if err := writeManifest(runDirectory, "basin", args, manifestConfiguration); err != nil {
+		return fmt.Errorf("could not write manifest: %w", err)
+	}
+
+	logger.Println("DONE")
+	return nil
+}
+
+// Create a probe by flipping exactly the given number of (randomly selected) units of a target state.
+//
+// Units are flipped with noiseapplication.MaximalInversion, which inverts bipolar units. Binary states are mapped to
+// the bipolar domain for the inversion, and back again afterwards.
+//
+// # Arguments
+//
+// randomGenerator *rand.Rand: The random generator selecting the flipped units
+//
+// networkDomain domain.DomainEnum: The domain of the target state
+//
+// targetState *mat.VecDense: The target state to create the probe from, which is not altered
+//
+// distance int: The number of units to flip, which is the Hamming distance of the probe from the target state
+//
+// # Returns
+//
+// The new probe state
+func flipTargetStateUnits(randomGenerator *rand.Rand, networkDomain domain.DomainEnum, targetState *mat.VecDense, distance int) *mat.VecDense {
+	probe := mat.VecDenseCopyOf(targetState)
+	if networkDomain == domain.BinaryDomain {
+		for i := 0; i < probe.Len(); i++ {
+			probe.SetVec(i, 2*probe.AtVec(i)-1)
+		}
+	}
+
+	// The inversion ratio is offset by half a unit so the number of flipped units is not lost to floating point truncation
+	inversionRatio := (float64(distance) + 0.5) / float64(probe.Len())
+	noiseapplication.GetNoiseApplicationMethod(noiseapplication.MaximalInversion)(randomGenerator, probe, inversionRatio)
+
+	if networkDomain == domain.BinaryDomain {
+		for i := 0; i < probe.Len(); i++ {
+			probe.SetVec(i, (probe.AtVec(i)+1)/2)
+		}
+	}
+	return probe
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"path"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+
+	"hmcalister/hopfield/hopfieldnetwork"
+	"hmcalister/hopfield/hopfieldnetwork/datacollector"
+)
+
+const (
+	CAPACITY_CONFIG_SAVE_FILE   = "capacityConfig.json"
+	CAPACITY_SEARCH_SAVE_FILE   = "capacitySearch.pq"
+	CAPACITY_ESTIMATE_SAVE_FILE = "capacityEstimate.pq"
+)
+
+// A description of a storage capacity estimate.
+//
+// Every combination of the parameter grids is searched Repeats times, each with a distinct seed, using Base for all other settings.
+// An empty grid leaves the value from Base unchanged.
+//
+// Each search bisects over the number of target states, between MinimumTargetStates and MaximumTargetStates (0 selects twice the
+// network dimension), for the largest number of target states learned stably. Target states are learned stably if at least
+// StabilityThreshold of them are stable after learning, so a threshold of 1.0 requires all target states to be stable.
+//
+// Seed is the base seed of the estimate, from which the seed of each search is derived. A seed of 0 selects a seed from the current time.
+type CapacityConfig struct {
+	Base                ExperimentConfig   `json:"base" yaml:"base"`
+	Parameters          CapacityParameters `json:"parameters" yaml:"parameters"`
+	Repeats             int                `json:"repeats" yaml:"repeats"`
+	Seed                uint64             `json:"seed" yaml:"seed"`
+	MinimumTargetStates int                `json:"minimumTargetStates" yaml:"minimumTargetStates"`
+	MaximumTargetStates int                `json:"maximumTargetStates" yaml:"maximumTargetStates"`
+	StabilityThreshold  float64            `json:"stabilityThreshold" yaml:"stabilityThreshold"`
+	ConfidenceLevel     float64            `json:"confidenceLevel" yaml:"confidenceLevel"`
+}
+
+// The parameter grids of a capacity estimate.
+type CapacityParameters struct {
+	Dimension          []int                              `json:"dimension" yaml:"dimension"`
+	LearningRule       []hopfieldnetwork.LearningRuleEnum `json:"learningRule" yaml:"learningRule"`
+	LearningNoiseScale []float64                          `json:"learningNoiseScale" yaml:"learningNoiseScale"`
+}
+
+// A single bisection search of a capacity estimate, ready to be run by a worker.
+type capacitySearch struct {
+	searchData          *datacollector.CapacitySearchData
+	config              *ExperimentConfig
+	configurationIndex  int
+	maximumTargetStates int
+}
+
+// Load a CapacityConfig from a JSON or YAML file, overwriting only the fields present in that file.
+func (capacityConfig *CapacityConfig) LoadFile(capacityConfigFilePath string) error {
+	return decodeConfigFile(capacityConfigFilePath, capacityConfig)
+}
+
+// Write the CapacityConfig to a file as JSON, so the resolved estimate (including the seed) is recorded alongside the data.
+func (capacityConfig *CapacityConfig) WriteFile(capacityConfigFilePath string) error {
+	return writeConfigFile(capacityConfigFilePath, capacityConfig)
+}
+
+// Expand the capacity estimate into the individual searches, in a deterministic order.
+//
+// Each search is validated, so an invalid combination of parameters is reported before any search is run.
+//
+// # Returns
+//
+// The searches, and the number of configurations (combinations of parameters) searched
+func (capacityConfig *CapacityConfig) expandSearches() ([]*capacitySearch, int, error) {
+	if capacityConfig.Repeats <= 0 {
+		return nil, 0, fmt.Errorf("repeats must be a positive integer, got %d", capacityConfig.Repeats)
+	}
+	if capacityConfig.MinimumTargetStates <= 0 {
+		return nil, 0, fmt.Errorf("minimumTargetStates must be a positive integer, got %d", capacityConfig.MinimumTargetStates)
+	}
+	if capacityConfig.StabilityThreshold <= 0.0 || capacityConfig.StabilityThreshold > 1.0 {
+		return nil, 0, fmt.Errorf("stabilityThreshold must be in the range (0.0, 1.0], got %v", capacityConfig.StabilityThreshold)
+	}
+	if capacityConfig.ConfidenceLevel <= 0.0 || capacityConfig.ConfidenceLevel >= 1.0 {
+		return nil, 0, fmt.Errorf("confidenceLevel must be in the range (0.0, 1.0), got %v", capacityConfig.ConfidenceLevel)
+	}
+
+	// Empty grids take the single value from the base configuration
+	dimensions := capacityConfig.Parameters.Dimension
+	if len(dimensions) == 0 {
+		dimensions = []int{capacityConfig.Base.Network.Dimension}
+	}
+	learningRules := capacityConfig.Parameters.LearningRule
+	if len(learningRules) == 0 {
+		learningRules = []hopfieldnetwork.LearningRuleEnum{capacityConfig.Base.Learning.Rule}
+	}
+	learningNoiseScales := capacityConfig.Parameters.LearningNoiseScale
+	if len(learningNoiseScales) == 0 {
+		learningNoiseScales = []float64{capacityConfig.Base.Learning.NoiseScale}
+	}
+
+	searches := []*capacitySearch{}
+	configurationIndex := 0
+	for _, dimension := range dimensions {
+		maximumTargetStates := capacityConfig.MaximumTargetStates
+		if maximumTargetStates == 0 {
+			maximumTargetStates = 2 * dimension
+		}
+		if maximumTargetStates < capacityConfig.MinimumTargetStates {
+			return nil, 0, fmt.Errorf("maximumTargetStates must be at least minimumTargetStates (%d) for dimension %d, got %d", capacityConfig.MinimumTargetStates, dimension, maximumTargetStates)
+		}
+		for _, learningRule := range learningRules {
+			for _, learningNoiseScale := range learningNoiseScales {
+				for repeat := 0; repeat < capacityConfig.Repeats; repeat++ {
+					searchIndex := len(searches)
+					// Each search uses two consecutive seeds (network and state generator) so we step by two
+					searchSeed := capacityConfig.Seed + 2*uint64(searchIndex) + 1
+
+					searchConfig := capacityConfig.Base
+					searchConfig.Seed = searchSeed
+					searchConfig.Network.Dimension = dimension
+					searchConfig.Learning.Rule = learningRule
+					searchConfig.Learning.NoiseScale = learningNoiseScale
+					// Only the stability of the target states is measured, so no states are loaded, probed or recorded
+					searchConfig.States.NumTargetStates = capacityConfig.MinimumTargetStates
+					searchConfig.States.TargetStatesFile = ""
+					searchConfig.States.NumProbeStates = 0
+					searchConfig.States.ProbeStatesFile = ""
+					searchConfig.DataCollection.LearnState = false
+					searchConfig.DataCollection.LearnEpoch = false
+					searchConfig.DataCollection.MatrixSnapshotInterval = 0
+					searchConfig.DataCollection.Spectrum = false
+					searchConfig.DataCollection.RelaxationHistory = false
+					if err := searchConfig.Validate(); err != nil {
+						return nil, 0, fmt.Errorf("search %d: %w", searchIndex, err)
+					}
+
+					searches = append(searches, &capacitySearch{
+						searchData: &datacollector.CapacitySearchData{
+							SearchIndex:        searchIndex,
+							Repeat:             repeat,
+							Seed:               int64(searchSeed),
+							NetworkDimension:   dimension,
+							LearningRule:       learningRule.String(),
+							LearningNoiseScale: learningNoiseScale,
+						},
+						config:              &searchConfig,
+						configurationIndex:  configurationIndex,
+						maximumTargetStates: maximumTargetStates,
+					})
+				}
+				configurationIndex += 1
+			}
+		}
+	}
+	return searches, configurationIndex, nil
+}
+
+// Run a storage capacity estimate from the command line arguments following the "capacity" subcommand.
+//
+// Searches are run in parallel by a bounded pool of workers. Once all searches are complete the result of each
+// search, and the estimate of the critical load (alpha) of each configuration, are written to the data directory.
+func runCapacityCommand(args []string) error {
+	capacityFlags := flag.NewFlagSet("capacity", flag.ExitOnError)
+	capacityConfigFilePath := capacityFlags.String("config", "", "Path to a JSON (.json) or YAML (.yaml, .yml) capacity configuration file, holding a base experiment, parameter grids and search settings.")
+	dimensionGrid := capacityFlags.String("dimension", "", "Comma separated network dimensions to estimate the capacity of. Overrides the grid in the capacity configuration.")
+	learningRuleGrid := capacityFlags.String("learningRule", "", "Comma separated learning rules (by name or integer value) to estimate the capacity of. Overrides the grid in the capacity configuration.")
+	learningNoiseScaleGrid := capacityFlags.String("learningNoiseScale", "", "Comma separated learning noise scales to estimate the capacity of. Overrides the grid in the capacity configuration.")
+	repeats := capacityFlags.Int("repeats", 0, "The number of searches of each combination of parameters. Overrides the value in the capacity configuration (default 10).")
+	capacitySeed := capacityFlags.Uint64("seed", 0, "The base seed of the estimate. Overrides the value in the capacity configuration. If 0, a seed is selected from the current time.")
+	minimumTargetStates := capacityFlags.Int("minimumTargetStates", 0, "The smallest number of target states searched. Overrides the value in the capacity configuration (default 1).")
+	maximumTargetStates := capacityFlags.Int("maximumTargetStates", 0, "The largest number of target states searched. Overrides the value in the capacity configuration (default twice the network dimension).")
+	stabilityThreshold := capacityFlags.Float64("stabilityThreshold", 0, "The fraction of target states that must be stable after learning. Overrides the value in the capacity configuration (default 1.0, all target states).")
+	confidenceLevel := capacityFlags.Float64("confidenceLevel", 0, "The confidence level of the interval of each alpha estimate. Overrides the value in the capacity configuration (default 0.95).")
+	numWorkers := capacityFlags.Int("workers", runtime.NumCPU(), "The number of searches to run in parallel.")
+	dataFileFlags := addDataFileFlags(capacityFlags, defaultExperimentConfig().DataCollection, "\nOverrides the value in the capacity configuration.")
+	outputFlags := addOutputFlags(capacityFlags, "data/hopfieldCapacity")
+	capacityFlags.Parse(args)
+
+	// The base experiment defaults to the same values as a single trial
+	capacityConfig := &CapacityConfig{
+		Base:                *defaultExperimentConfig(),
+		Repeats:             10,
+		MinimumTargetStates: 1,
+		StabilityThreshold:  1.0,
+		ConfidenceLevel:     0.95,
+	}
+	if *capacityConfigFilePath != "" {
+		if err := capacityConfig.LoadFile(*capacityConfigFilePath); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if *dimensionGrid != "" {
+		if capacityConfig.Parameters.Dimension, err = parseGrid(*dimensionGrid, strconv.Atoi); err != nil {
+			return fmt.Errorf("could not parse dimension grid: %w", err)
+		}
+	}
+	if *learningRuleGrid != "" {
+		if capacityConfig.Parameters.LearningRule, err = parseGrid(*learningRuleGrid, hopfieldnetwork.ParseLearningRuleEnum); err != nil {
+			return fmt.Errorf("could not parse learningRule grid: %w", err)
+		}
+	}
+	if *learningNoiseScaleGrid != "" {
+		parseFloat := func(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+		if capacityConfig.Parameters.LearningNoiseScale, err = parseGrid(*learningNoiseScaleGrid, parseFloat); err != nil {
+			return fmt.Errorf("could not parse learningNoiseScale grid: %w", err)
+		}
+	}
+	if *repeats != 0 {
+		capacityConfig.Repeats = *repeats
+	}
+	if *capacitySeed != 0 {
+		capacityConfig.Seed = *capacitySeed
+	}
+	if *minimumTargetStates != 0 {
+		capacityConfig.MinimumTargetStates = *minimumTargetStates
+	}
+	if *maximumTargetStates != 0 {
+		capacityConfig.MaximumTargetStates = *maximumTargetStates
+	}
+	if *stabilityThreshold != 0 {
+		capacityConfig.StabilityThreshold = *stabilityThreshold
+	}
+	if *confidenceLevel != 0 {
+		capacityConfig.ConfidenceLevel = *confidenceLevel
+	}
+	dataFileFlags.applyTo(&capacityConfig.Base.DataCollection, true)
+	if capacityConfig.Seed == 0 {
+		capacityConfig.Seed = uint64(time.Now().UnixNano())
+	}
+	if *numWorkers <= 0 {
+		return fmt.Errorf("workers must be a positive integer, got %d", *numWorkers)
+	}
+
+	searches, numConfigurations, err := capacityConfig.expandSearches()
+	if err != nil {
+		return err
+	}
+
+	defer startProfiling(*outputFlags.enableProfiling)()
+
+	capacityDataDirectory, err := outputFlags.createRunDirectory()
+	if err != nil {
+		return err
+	}
+	logger, err := outputFlags.newLogger(capacityDataDirectory)
+	if err != nil {
+		return fmt.Errorf("could not open log file: %w", err)
+	}
+	logger.Printf("Writing to capacity directory %#v\n", capacityDataDirectory)
+
+	// Record the resolved capacity configuration, including the seed, so the estimate can be repeated exactly
+	if err := capacityConfig.WriteFile(path.Join(capacityDataDirectory, CAPACITY_CONFIG_SAVE_FILE)); err != nil {
+		return err
+	}
+
+	logger.Printf("Running %d searches with %d workers\n", len(searches), *numWorkers)
+	searchChannel := make(chan *capacitySearch)
+	var workerGroup sync.WaitGroup
+	for workerIndex := 0; workerIndex < *numWorkers; workerIndex++ {
+		workerGroup.Add(1)
+		go func() {
+			defer workerGroup.Done()
+			for search := range searchChannel {
+				search.run(capacityConfig.MinimumTargetStates, capacityConfig.StabilityThreshold)
+				logger.Printf("Finished search %d (critical target states %d, %d evaluations)\n",
+					search.searchData.SearchIndex, search.searchData.CriticalTargetStates, search.searchData.Evaluations)
+			}
+		}()
+	}
+	for _, search := range searches {
+		searchChannel <- search
+	}
+	close(searchChannel)
+	workerGroup.Wait()
+
+	// AGGREGATE SEARCHES -------------------------------------------------------------------------
+	searchData := make([]*datacollector.CapacitySearchData, len(searches))
+	configurationSearches := make([][]*datacollector.CapacitySearchData, numConfigurations)
+	for searchIndex, search := range searches {
+		searchData[searchIndex] = search.searchData
+		configurationSearches[search.configurationIndex] = append(configurationSearches[search.configurationIndex], search.searchData)
+	}
+	estimateData := make([]*datacollector.CapacityEstimateData, numConfigurations)
+	for configurationIndex, configurationSearchData := range configurationSearches {
+		estimateData[configurationIndex] = estimateCapacity(configurationSearchData, capacityConfig.ConfidenceLevel)
+		estimate := estimateData[configurationIndex]
+		logger.Printf("Dimension %d, %v, noise %v: alpha = %.4f (%.4f, %.4f)\n", estimate.NetworkDimension, estimate.LearningRule,
+			estimate.LearningNoiseScale, estimate.MeanAlpha, estimate.AlphaLowerBound, estimate.AlphaUpperBound)
+	}
+
+	dataFileSettings := capacityConfig.Base.DataCollection.DataFileSettings()
+	outputFormat := capacityConfig.Base.DataCollection.OutputFormat
+	if err := datacollector.WriteCapacitySearches(path.Join(capacityDataDirectory, dataFileName(CAPACITY_SEARCH_SAVE_FILE, outputFormat)), dataFileSettings, searchData); err != nil {
+		return fmt.Errorf("capacity search saving failed: %w", err)
+	}
+	if err := datacollector.WriteCapacityEstimates(path.Join(capacityDataDirectory, dataFileName(CAPACITY_ESTIMATE_SAVE_FILE, outputFormat)), dataFileSettings, estimateData); err != nil {
+		return fmt.Errorf("capacity estimate saving failed: %w", err)
+	}
+	if err := writeManifest(capacityDataDirectory, "capacity", args, capacityConfig); err != nil {
+		return fmt.Errorf("could not write manifest: %w", err)
+	}
+
+	logger.Println("DONE")
+	return nil
+}
+
+// Bisect over the number of target states for the largest number learned stably, recording the result in the search data.
+//
+// The number of target states learned stably is assumed to be monotonic, i.e. if a number of target states is learned
+// stably then so is any smaller number. Every network of the search uses the same seed, so the target states of a smaller
+// network are the first target states of a larger one.
+func (search *capacitySearch) run(minimumTargetStates int, stabilityThreshold float64) {
+	isStable := func(numTargetStates int) bool {
+		search.searchData.Evaluations += 1
+		return targetStatesAreStable(search.config, numTargetStates, stabilityThreshold)
+	}
+
+	lowerTargetStates, upperTargetStates := minimumTargetStates, search.maximumTargetStates
+	switch {
+	case !isStable(lowerTargetStates):
+		search.searchData.CriticalTargetStates = 0
+	case isStable(upperTargetStates):
+		search.searchData.CriticalTargetStates = upperTargetStates
+		search.searchData.ReachedMaximum = true
+	default:
+		// The lower bound is always stable and the upper bound is always unstable
+		for upperTargetStates-lowerTargetStates > 1 {
+			middleTargetStates := (lowerTargetStates + upperTargetStates) / 2
+			if isStable(middleTargetStates) {
+				lowerTargetStates = middleTargetStates
+			} else {
+				upperTargetStates = middleTargetStates
+			}
+		}
+		search.searchData.CriticalTargetStates = lowerTargetStates
+	}
+	search.searchData.Alpha = float64(search.searchData.CriticalTargetStates) / float64(search.searchData.NetworkDimension)
+}
+
+// Learn a number of target states on a new network, and determine if at least stabilityThreshold of them are stable.
+//
+// A threshold of 1.0 (or more) requires all target states to be stable, as measured by HopfieldNetwork.AllStatesAreStable.
+func targetStatesAreStable(config *ExperimentConfig, numTargetStates int, stabilityThreshold float64) bool {
+	networkConfig := *config
+	networkConfig.States.NumTargetStates = numTargetStates
+
+	// No data is collected, but the network requires a collector to send events to
+	collector := datacollector.NewBufferedDataCollector(DATA_COLLECTOR_BUFFER_SIZE)
+	collector.Start()
+	defer collector.Close()
+
+	network := newNetworkBuilder(&networkConfig, collector, log.New(io.Discard, "", 0)).Build()
+	targetStates := newStateGenerator(&networkConfig).CreateStateCollection(numTargetStates)
+	network.LearnStates(targetStates)
+
+	if stabilityThreshold >= 1.0 {
+		return network.AllStatesAreStable(targetStates)
+	}
+	stableTargetStates := 0
+	for _, targetState := range targetStates {
+		if network.StateIsStable(targetState) {
+			stableTargetStates += 1
+		}
+	}
+	return float64(stableTargetStates) >= stabilityThreshold*float64(numTargetStates)
+}
+
+// Aggregate the searches of a single configuration into an estimate of the critical load, with a confidence interval of the mean.
+func estimateCapacity(searchData []*datacollector.CapacitySearchData, confidenceLevel float64) *datacollector.CapacityEstimateData {
+	alphas := make([]float64, len(searchData))
+	criticalTargetStates := make([]float64, len(searchData))
+	for searchIndex, search := range searchData {
+		alphas[searchIndex] = search.Alpha
+		criticalTargetStates[searchIndex] = float64(search.CriticalTargetStates)
+	}
+
+	estimate := &datacollector.CapacityEstimateData{
+		NetworkDimension:         searchData[0].NetworkDimension,
+		LearningRule:             searchData[0].LearningRule,
+		LearningNoiseScale:       searchData[0].LearningNoiseScale,
+		Searches:                 len(searchData),
+		MeanCriticalTargetStates: stat.Mean(criticalTargetStates, nil),
+		ConfidenceLevel:          confidenceLevel,
+	}
+	estimate.MeanAlpha = stat.Mean(alphas, nil)
+	estimate.AlphaLowerBound = estimate.MeanAlpha
+	estimate.AlphaUpperBound = estimate.MeanAlpha
+	if len(alphas) > 1 {
+		estimate.AlphaStandardDeviation = stat.StdDev(alphas, nil)
+		studentsT := distuv.StudentsT{Mu: 0, Sigma: 1, Nu: float64(len(alphas) - 1)}
+		halfWidth := studentsT.Quantile(0.5+confidenceLevel/2) * estimate.AlphaStandardDeviation / math.Sqrt(float64(len(alphas)))
+		estimate.AlphaLowerBound -= halfWidth
+		estimate.AlphaUpperBound += halfWidth
+	}
+	return estimate
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"hmcalister/hopfield/hopfieldnetwork"
+	"hmcalister/hopfield/hopfieldnetwork/datacollector"
+	"hmcalister/hopfield/hopfieldnetwork/distancemeasure"
+	"hmcalister/hopfield/hopfieldnetwork/domain"
+	"hmcalister/hopfield/hopfieldnetwork/noiseapplication"
+)
+
+// A declarative description of an experiment.
+//
+// An ExperimentConfig can be loaded from a JSON or YAML file using the -config flag.
+// Enums are written by name (e.g. "DeltaLearningRule") although integer values are also accepted.
+// Any field not present in the file keeps the value given by the command line flags (or the flag default).
+//
+// Seed is used to seed both the network and the state generator. A seed of 0 selects a seed from the current time.
+type ExperimentConfig struct {
+	Seed           uint64               `json:"seed" yaml:"seed"`
+	Network        NetworkConfig        `json:"network" yaml:"network"`
+	Learning       LearningConfig       `json:"learning" yaml:"learning"`
+	States         StatesConfig         `json:"states" yaml:"states"`
+	Probing        ProbingConfig        `json:"probing" yaml:"probing"`
+	DataCollection DataCollectionConfig `json:"dataCollection" yaml:"dataCollection"`
+}
+
+// Settings passed to the HopfieldNetworkBuilder that define the network itself.
+type NetworkConfig struct {
+	Domain                         domain.DomainEnum                   `json:"domain" yaml:"domain"`
+	Dimension                      int                                 `json:"dimension" yaml:"dimension"`
+	ForceSymmetric                 bool                                `json:"forceSymmetric" yaml:"forceSymmetric"`
+	ForceZeroDiagonal              bool                                `json:"forceZeroDiagonal" yaml:"forceZeroDiagonal"`
+	DistanceMeasure                distancemeasure.DistanceMeasureEnum `json:"distanceMeasure" yaml:"distanceMeasure"`
+	RandomMatrixInit               bool                                `json:"randomMatrixInit" yaml:"randomMatrixInit"`
+	UnitsUpdated                   int                                 `json:"unitsUpdated" yaml:"unitsUpdated"`
+	MaximumRelaxationIterations    int                                 `json:"maximumRelaxationIterations" yaml:"maximumRelaxationIterations"`
+	MaximumRelaxationUnstableUnits int                                 `json:"maximumRelaxationUnstableUnits" yaml:"maximumRelaxationUnstableUnits"`
+}
+
+// Settings that define how the network learns the target states.
+type LearningConfig struct {
+	Method      hopfieldnetwork.LearningMethodEnum    `json:"method" yaml:"method"`
+	Rule        hopfieldnetwork.LearningRuleEnum      `json:"rule" yaml:"rule"`
+	Epochs      int                                   `json:"epochs" yaml:"epochs"`
+	Rate        float64                               `json:"rate" yaml:"rate"`
+	NoiseMethod noiseapplication.NoiseApplicationEnum `json:"noiseMethod" yaml:"noiseMethod"`
+	NoiseScale  float64                               `json:"noiseScale" yaml:"noiseScale"`
+}
+
+// Settings that define how target and probe states are generated (or loaded).
+//
+// If a states file is given the corresponding number of states is ignored, and set from the file instead.
+type StatesConfig struct {
+	NumTargetStates  int    `json:"numTargetStates" yaml:"numTargetStates"`
+	TargetStatesFile string `json:"targetStatesFile" yaml:"targetStatesFile"`
+	NumProbeStates   int    `json:"numProbeStates" yaml:"numProbeStates"`
+	ProbeStatesFile  string `json:"probeStatesFile" yaml:"probeStatesFile"`
+}
+
+// Settings that define how probe states are relaxed.
+type ProbingConfig struct {
+	Threads int `json:"threads" yaml:"threads"`
+}
+
+// Flags to enable each of the data handlers, and the format all data files (including the network summary) are written in.
+//
+// Note that RelaxationHistory is very intensive, and also enables intensive data collection in the network.
+// The RelaxationHistoryPolicy selects the history recorded, see hopfieldnetwork.RelaxationHistoryPolicy.
+//
+// LearnEpoch records weight matrix statistics after each epoch of learning, including the top LearnEpochEigenvalues
+// eigenvalues. A MatrixSnapshotInterval greater than 0 saves the full weight matrix every MatrixSnapshotInterval epochs.
+// Spectrum writes the spectrum of the learned weight matrix, and the alignment of each target state with its eigenvectors.
+type DataCollectionConfig struct {
+	RelaxationResult        bool                                    `json:"relaxationResult" yaml:"relaxationResult"`
+	TargetStateProbe        bool                                    `json:"targetStateProbe" yaml:"targetStateProbe"`
+	UniqueRelaxedStates     bool                                    `json:"uniqueRelaxedStates" yaml:"uniqueRelaxedStates"`
+	LearnState              bool                                    `json:"learnState" yaml:"learnState"`
+	LearnEpoch              bool                                    `json:"learnEpoch" yaml:"learnEpoch"`
+	LearnEpochEigenvalues   int                                     `json:"learnEpochEigenvalues" yaml:"learnEpochEigenvalues"`
+	MatrixSnapshotInterval  int                                     `json:"matrixSnapshotInterval" yaml:"matrixSnapshotInterval"`
+	Spectrum                bool                                    `json:"spectrum" yaml:"spectrum"`
+	RelaxationHistory       bool                                    `json:"relaxationHistory" yaml:"relaxationHistory"`
+	RelaxationHistoryPolicy hopfieldnetwork.RelaxationHistoryPolicy `json:"relaxationHistoryPolicy" yaml:"relaxationHistoryPolicy"`
+	OutputFormat            datacollector.OutputFormatEnum          `json:"outputFormat" yaml:"outputFormat"`
+	ParquetWriter           datacollector.ParquetWriterSettings     `json:"parquetWriter" yaml:"parquetWriter"`
+}
+
+// Get the settings of the data files written with this configuration.
+func (config DataCollectionConfig) DataFileSettings() datacollector.DataFileSettings {
+	return datacollector.DataFileSettings{
+		OutputFormat: config.OutputFormat,
+		Parquet:      config.ParquetWriter,
+	}
+}
+
+// Load an ExperimentConfig from a file, overwriting only the fields present in that file.
+//
+// The file format is determined by extension: .json files are read as JSON, .yaml and .yml files as YAML.
+// Unknown fields are treated as errors to catch typos in configuration files.
+//
+// # Arguments
+//
+// configFilePath string: The path to the configuration file
+//
+// # Returns
+//
+// An error if the file could not be read or decoded, nil otherwise
+func (config *ExperimentConfig) LoadFile(configFilePath string) error {
+	return decodeConfigFile(configFilePath, config)
+}
+
+// Decode a JSON or YAML configuration file into the given struct, selecting the format by file extension.
+//
+// Only the fields present in the file are overwritten. Unknown fields are treated as errors.
+func decodeConfigFile(configFilePath string, config interface{}) error {
+	configData, err := os.ReadFile(configFilePath)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(configFilePath)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(configData))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(config)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(configData))
+		decoder.KnownFields(true)
+		err = decoder.Decode(config)
+	default:
+		return fmt.Errorf("configuration file %v has unknown extension, expected one of .json, .yaml, .yml", configFilePath)
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not decode configuration file %v: %w", configFilePath, err)
+	}
+	return nil
+}
+
+// Write a configuration struct to a file as indented JSON.
+func writeConfigFile(configFilePath string, config interface{}) error {
+	configData, err := json.MarshalIndent(config, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(configFilePath, configData, 0644)
+}
+
+// Write the ExperimentConfig to a file as JSON, so the resolved configuration of a trial is recorded alongside the data.
+//
+// A file written by this method can be loaded again using the -config flag.
+func (config *ExperimentConfig) WriteFile(configFilePath string) error {
+	return writeConfigFile(configFilePath, config)
+}
+
+// Check the ExperimentConfig for invalid values.
+//
+// All problems are collected (rather than stopping at the first) so a configuration file can be fixed in one pass.
+//
+// # Returns
+//
+// An error describing every invalid field, or nil if the configuration is valid
+func (config *ExperimentConfig) Validate() error {
+	problems := []string{}
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if _, err := domain.ParseDomainEnum(config.Network.Domain.String()); err != nil {
+		addProblem("network.domain: %v", err)
+	}
+	if _, err := distancemeasure.ParseDistanceMeasureEnum(config.Network.DistanceMeasure.String()); err != nil {
+		addProblem("network.distanceMeasure: %v", err)
+	}
+	if config.Network.Dimension <= 0 {
+		addProblem("network.dimension must be a positive integer, got %d", config.Network.Dimension)
+	}
+	if config.Network.UnitsUpdated <= 0 || config.Network.UnitsUpdated > config.Network.Dimension {
+		addProblem("network.unitsUpdated must be a positive integer no larger than network.dimension, got %d", config.Network.UnitsUpdated)
+	}
+	if config.Network.MaximumRelaxationIterations <= 0 {
+		addProblem("network.maximumRelaxationIterations must be a positive integer, got %d", config.Network.MaximumRelaxationIterations)
+	}
+	if config.Network.MaximumRelaxationUnstableUnits < 0 {
+		addProblem("network.maximumRelaxationUnstableUnits must not be negative, got %d", config.Network.MaximumRelaxationUnstableUnits)
+	}
+
+	if _, err := hopfieldnetwork.ParseLearningMethodEnum(config.Learning.Method.String()); err != nil {
+		addProblem("learning.method: %v", err)
+	}
+	if _, err := hopfieldnetwork.ParseLearningRuleEnum(config.Learning.Rule.String()); err != nil {
+		addProblem("learning.rule: %v", err)
+	}
+	if config.Learning.Epochs <= 0 {
+		addProblem("learning.epochs must be a positive integer, got %d", config.Learning.Epochs)
+	}
+	if config.Learning.Rate <= 0.0 {
+		addProblem("learning.rate must be greater than 0.0, got %v", config.Learning.Rate)
+	}
+	if _, err := noiseapplication.ParseNoiseApplicationEnum(config.Learning.NoiseMethod.String()); err != nil {
+		addProblem("learning.noiseMethod: %v", err)
+	}
+	switch config.Learning.NoiseMethod {
+	case noiseapplication.MaximalInversion, noiseapplication.RandomSubMaximalInversion:
+		// The noise scale is the ratio of units inverted
+		if config.Learning.NoiseScale < 0.0 || config.Learning.NoiseScale > 1.0 {
+			addProblem("learning.noiseScale must be in the range [0.0, 1.0] for %v, got %v", config.Learning.NoiseMethod, config.Learning.NoiseScale)
+		}
+	default:
+		// The noise scale is a standard deviation for GaussianApplication, and unused otherwise
+		if config.Learning.NoiseScale < 0.0 {
+			addProblem("learning.noiseScale must not be negative, got %v", config.Learning.NoiseScale)
+		}
+	}
+
+	if config.States.TargetStatesFile == "" && config.States.NumTargetStates <= 0 {
+		addProblem("states.numTargetStates must be a positive integer when no states.targetStatesFile is given, got %d", config.States.NumTargetStates)
+	}
+	if config.States.ProbeStatesFile == "" && config.States.NumProbeStates < 0 {
+		addProblem("states.numProbeStates must not be negative, got %d", config.States.NumProbeStates)
+	}
+	for _, statesFile := range []string{config.States.TargetStatesFile, config.States.ProbeStatesFile} {
+		if statesFile == "" {
+			continue
+		}
+		if _, err := os.Stat(statesFile); err != nil {
+			addProblem("states file %v cannot be read: %v", statesFile, err)
+		}
+	}
+
+	if config.Probing.Threads <= 0 {
+		addProblem("probing.threads must be a positive integer, got %d", config.Probing.Threads)
+	}
+
+	if config.DataCollection.LearnEpochEigenvalues < 0 {
+		addProblem("dataCollection.learnEpochEigenvalues must not be negative, got %d", config.DataCollection.LearnEpochEigenvalues)
+	}
+	if config.DataCollection.MatrixSnapshotInterval < 0 {
+		addProblem("dataCollection.matrixSnapshotInterval must not be negative, got %d", config.DataCollection.MatrixSnapshotInterval)
+	}
+
+	historyPolicy := config.DataCollection.RelaxationHistoryPolicy
+	if historyPolicy.StepInterval < 1 {
+		addProblem("dataCollection.relaxationHistoryPolicy.stepInterval must be a positive integer, got %d", historyPolicy.StepInterval)
+	}
+	if historyPolicy.SampleSize < 0 {
+		addProblem("dataCollection.relaxationHistoryPolicy.sampleSize must not be negative, got %d", historyPolicy.SampleSize)
+	}
+	for _, probeIndex := range historyPolicy.ProbeIndices {
+		if probeIndex < 0 {
+			addProblem("dataCollection.relaxationHistoryPolicy.probeIndices must not be negative, got %d", probeIndex)
+		}
+	}
+
+	if _, err := datacollector.ParseOutputFormatEnum(config.DataCollection.OutputFormat.String()); err != nil {
+		addProblem("dataCollection.outputFormat: %v", err)
+	}
+	if _, err := datacollector.ParseParquetCompressionEnum(config.DataCollection.ParquetWriter.Compression.String()); err != nil {
+		addProblem("dataCollection.parquetWriter.compression: %v", err)
+	}
+	if config.DataCollection.ParquetWriter.RowGroupSizeMB <= 0 {
+		addProblem("dataCollection.parquetWriter.rowGroupSizeMB must be a positive integer, got %d", config.DataCollection.ParquetWriter.RowGroupSizeMB)
+	}
+	if config.DataCollection.ParquetWriter.PageSizeKB <= 0 {
+		addProblem("dataCollection.parquetWriter.pageSizeKB must be a positive integer, got %d", config.DataCollection.ParquetWriter.PageSizeKB)
+	}
+	if config.DataCollection.ParquetWriter.Parallelism <= 0 {
+		addProblem("dataCollection.parquetWriter.parallelism must be a positive integer, got %d", config.DataCollection.ParquetWriter.Parallelism)
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid experiment configuration:\n\t%v", strings.Join(problems, "\n\t"))
+}
@@ -0,0 +1,503 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"hmcalister/hopfield/hopfieldnetwork"
+	"hmcalister/hopfield/hopfieldnetwork/datacollector"
+	"hmcalister/hopfield/hopfieldnetwork/distancemeasure"
+	"hmcalister/hopfield/hopfieldnetwork/domain"
+	"hmcalister/hopfield/hopfieldnetwork/noiseapplication"
+)
+
+// The format of the timestamp naming a run directory, chosen to sort lexically and be safe on all file systems
+const RUN_DIRECTORY_TIME_FORMAT = "20060102-150405"
+
+// The command line flags describing an experiment, shared by the commands that build and train networks.
+//
+// Enum flags are parsed by name (or integer value) using TextVar.
+type experimentFlags struct {
+	flagSet                *flag.FlagSet
+	configFilePath         *string
+	seed                   *uint64
+	forceSymmetric         *bool
+	randomMatrixInit       *bool
+	networkDimension       *int
+	unitsUpdated           *int
+	numEpochs              *int
+	numTargetStates        *int
+	targetStatesFile       *string
+	learningRate           *float64
+	learningNoiseScale     *float64
+	learnEpochEigenvalues  *int
+	matrixSnapshotInterval *int
+	networkDomain          domain.DomainEnum
+	distanceMeasure        distancemeasure.DistanceMeasureEnum
+	learningMethod         hopfieldnetwork.LearningMethodEnum
+	learningRule           hopfieldnetwork.LearningRuleEnum
+	learningNoiseMethod    noiseapplication.NoiseApplicationEnum
+	dataFileFlags          *dataFileFlags
+}
+
+// The command line flags describing how probe states are relaxed, shared by the commands that learn and then probe networks.
+type probingFlags struct {
+	flagSet                      *flag.FlagSet
+	numProbeStates               *int
+	probeStatesFile              *string
+	numThreads                   *int
+	allowIntensiveDataCollection *bool
+	relaxationHistoryFlags       *relaxationHistoryFlags
+}
+
+// The command line flags describing the relaxation history policy, shared by all commands relaxing probe states.
+type relaxationHistoryFlags struct {
+	flagSet      *flag.FlagSet
+	stepInterval *int
+	sampleSize   *int
+	probeIndices []int
+	overlapsOnly *bool
+	spuriousOnly *bool
+}
+
+// The command line flags describing how data files are written, shared by all commands writing data files.
+type dataFileFlags struct {
+	flagSet               *flag.FlagSet
+	outputFormat          datacollector.OutputFormatEnum
+	parquetCompression    datacollector.ParquetCompressionEnum
+	parquetRowGroupSizeMB *int64
+	parquetPageSizeKB     *int64
+	parquetParallelism    *int64
+}
+
+// The command line flags describing where a command writes its output.
+//
+// Each run of a command writes to its own run directory within the data directory, see createRunDirectory.
+type outputFlags struct {
+	dataDirectory   *string
+	runName         *string
+	overwrite       *bool
+	logFilePath     *string
+	verbose         *bool
+	enableProfiling *bool
+}
+
+// Get the default configuration of an experiment, used as the defaults of the experiment flags.
+func defaultExperimentConfig() *ExperimentConfig {
+	return &ExperimentConfig{
+		Seed: 0,
+		Network: NetworkConfig{
+			Domain:                         domain.BipolarDomain,
+			Dimension:                      100,
+			ForceSymmetric:                 true,
+			ForceZeroDiagonal:              true,
+			DistanceMeasure:                distancemeasure.ManhattanDistanceWithInversion,
+			RandomMatrixInit:               false,
+			UnitsUpdated:                   1,
+			MaximumRelaxationIterations:    100,
+			MaximumRelaxationUnstableUnits: 0,
+		},
+		Learning: LearningConfig{
+			Method:      hopfieldnetwork.FullSetMethod,
+			Rule:        hopfieldnetwork.HebbianLearningRule,
+			Epochs:      100,
+			Rate:        1.0,
+			NoiseMethod: noiseapplication.None,
+			NoiseScale:  0.0,
+		},
+		States: StatesConfig{
+			NumTargetStates: 1,
+			NumProbeStates:  1000,
+		},
+		Probing: ProbingConfig{
+			Threads: 1,
+		},
+		DataCollection: DataCollectionConfig{
+			RelaxationResult:        true,
+			TargetStateProbe:        true,
+			UniqueRelaxedStates:     true,
+			LearnState:              true,
+			LearnEpoch:              true,
+			LearnEpochEigenvalues:   0,
+			MatrixSnapshotInterval:  0,
+			Spectrum:                true,
+			RelaxationHistory:       false,
+			RelaxationHistoryPolicy: hopfieldnetwork.DefaultRelaxationHistoryPolicy(),
+			OutputFormat:            datacollector.ParquetFormat,
+			ParquetWriter:           datacollector.DefaultParquetWriterSettings(),
+		},
+	}
+}
+
+// Register the experiment flags on a flag set.
+//
+// # Arguments
+//
+// flagSet *flag.FlagSet: The flag set to register the flags on
+//
+// # Returns
+//
+// The experimentFlags, which are populated once the flag set is parsed
+func addExperimentFlags(flagSet *flag.FlagSet) *experimentFlags {
+	defaults := defaultExperimentConfig()
+	flags := &experimentFlags{flagSet: flagSet}
+
+	// Experiment configuration flag
+
+	flags.configFilePath = flagSet.String("config", "", "Path to a JSON (.json) or YAML (.yaml, .yml) experiment configuration file. Experiment flags given explicitly override values in the file.")
+	flags.seed = flagSet.Uint64("seed", defaults.Seed, "The seed for the random generators of the network and states. If 0, a seed is selected from the current time.")
+
+	// General network flags
+
+	flagSet.TextVar(&flags.networkDomain, "domain", defaults.Network.Domain, "The network domain, by name or integer value.\n0: BipolarDomain\n1: BinaryDomain")
+	flags.forceSymmetric = flagSet.Bool("forceSymmetric", defaults.Network.ForceSymmetric, "Force the weight matrix of the Hopfield network to be symmetric.")
+	flags.randomMatrixInit = flagSet.Bool("randomMatrixInit", defaults.Network.RandomMatrixInit, "Flag to randomly initialize the matrix to small random values (for asymmetric seed).")
+	flags.networkDimension = flagSet.Int("dimension", defaults.Network.Dimension, "The network dimension to simulate.")
+	flags.unitsUpdated = flagSet.Int("unitsUpdated", defaults.Network.UnitsUpdated, "The number of units to update at each step.")
+	flagSet.TextVar(&flags.distanceMeasure, "distanceMeasure", defaults.Network.DistanceMeasure, "The measure of distance from relaxed states to target states, by name or integer value.\n0: ManhattanDistance\n1: ManhattanDistanceWithInversion\n2: EuclideanDistance\n3: EuclideanDistanceWithInversion\n4: HammingDistance\n5: HammingDistanceWithInversion\n6: OverlapDistance\n7: OverlapDistanceWithInversion\n8: CosineDistance\n9: CosineDistanceWithInversion")
+
+	// Learning method and rule flags
+
+	flagSet.TextVar(&flags.learningMethod, "learningMethod", defaults.Learning.Method, "The learning method to use, by name or integer value.\n0: FullSetMethod\n1: IterativeBatchMethod")
+	flagSet.TextVar(&flags.learningRule, "learningRule", defaults.Learning.Rule, "The learning rule to use, by name or integer value.\n0: HebbianLearningRule\n1: BipolarMappedHebbianLearningRule\n2: DeltaLearningRule\n3: BipolarMappedDeltaLearningRule\n4: ThermalDeltaLearningRule\n5: BipolarMappedThermalDeltaLearningRule")
+	flags.numEpochs = flagSet.Int("epochs", defaults.Learning.Epochs, "The number of epochs to train for.")
+
+	// Target state flags
+
+	flags.numTargetStates = flagSet.Int("numTargetStates", defaults.States.NumTargetStates, "The number of learned states.")
+	flags.targetStatesFile = flagSet.String("targetStatesFile", "", "Path to the binary (gonumio) or .npy file containing the vector collection to use as target states. If present, this method overrides random generation using numTargetStates.")
+
+	// Learning noise flags
+
+	flags.learningRate = flagSet.Float64("learningRate", defaults.Learning.Rate, "The learning rate of the network. Should be greater than 0.0.")
+	flagSet.TextVar(&flags.learningNoiseMethod, "learningNoiseMethod", defaults.Learning.NoiseMethod, "The method of applying noise to learned states, by name or integer value. Noise scale is determined by the learningNoiseScale Flag.\n0: None\n1: MaximalInversion\n2: RandomSubMaximalInversion\n3: GaussianApplication")
+	flags.learningNoiseScale = flagSet.Float64("learningNoiseScale", defaults.Learning.NoiseScale, "The amount of noise to apply to target states during learning.")
+
+	// Data collection flags
+
+	flags.learnEpochEigenvalues = flagSet.Int("learnEpochEigenvalues", defaults.DataCollection.LearnEpochEigenvalues, "The number of top eigenvalues of the weight matrix to record after each epoch of learning. 0 skips the (expensive) eigendecomposition.")
+	flags.matrixSnapshotInterval = flagSet.Int("matrixSnapshotInterval", defaults.DataCollection.MatrixSnapshotInterval, "The number of epochs between snapshots of the full weight matrix, saved in the matrixSnapshots directory. 0 disables snapshots.")
+	flags.dataFileFlags = addDataFileFlags(flagSet, defaults.DataCollection, "")
+
+	return flags
+}
+
+// Resolve the ExperimentConfig described by the (parsed) experiment flags, and the probing flags if the command probes the network.
+//
+// The configuration file (if given) is first loaded over the default configuration, then the flags given explicitly on the
+// command line are applied, and finally the result is validated. Flags not given explicitly do not override values in the file.
+// A seed of 0 is replaced by a seed selected from the current time.
+//
+// # Arguments
+//
+// probing *probingFlags: The probing flags registered on the same flag set, or nil if the command does not probe the network
+//
+// # Returns
+//
+// (resolved configuration, nil) on success, (nil, error) if the configuration file could not be loaded or the configuration is invalid
+func (flags *experimentFlags) resolveConfig(probing *probingFlags) (*ExperimentConfig, error) {
+	config := defaultExperimentConfig()
+	if *flags.configFilePath != "" {
+		if err := config.LoadFile(*flags.configFilePath); err != nil {
+			return nil, fmt.Errorf("configuration loading failed: %w", err)
+		}
+	}
+
+	flags.flagSet.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "seed":
+			config.Seed = *flags.seed
+		case "domain":
+			config.Network.Domain = flags.networkDomain
+		case "dimension":
+			config.Network.Dimension = *flags.networkDimension
+		case "forceSymmetric":
+			config.Network.ForceSymmetric = *flags.forceSymmetric
+		case "randomMatrixInit":
+			config.Network.RandomMatrixInit = *flags.randomMatrixInit
+		case "unitsUpdated":
+			config.Network.UnitsUpdated = *flags.unitsUpdated
+		case "distanceMeasure":
+			config.Network.DistanceMeasure = flags.distanceMeasure
+		case "learningMethod":
+			config.Learning.Method = flags.learningMethod
+		case "learningRule":
+			config.Learning.Rule = flags.learningRule
+		case "epochs":
+			config.Learning.Epochs = *flags.numEpochs
+		case "learningRate":
+			config.Learning.Rate = *flags.learningRate
+		case "learningNoiseMethod":
+			config.Learning.NoiseMethod = flags.learningNoiseMethod
+		case "learningNoiseScale":
+			config.Learning.NoiseScale = *flags.learningNoiseScale
+		case "numTargetStates":
+			config.States.NumTargetStates = *flags.numTargetStates
+		case "targetStatesFile":
+			config.States.TargetStatesFile = *flags.targetStatesFile
+		case "learnEpochEigenvalues":
+			config.DataCollection.LearnEpochEigenvalues = *flags.learnEpochEigenvalues
+		case "matrixSnapshotInterval":
+			config.DataCollection.MatrixSnapshotInterval = *flags.matrixSnapshotInterval
+		}
+	})
+	flags.dataFileFlags.applyTo(&config.DataCollection, true)
+	if probing != nil {
+		probing.applyTo(config)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	// Select the seed now, rather than in the network builder, so the seed is recorded with the run
+	if config.Seed == 0 {
+		config.Seed = uint64(time.Now().UnixNano())
+	}
+	return config, nil
+}
+
+// Register the probing flags on a flag set, for commands that probe the network they learn.
+//
+// # Arguments
+//
+// flagSet *flag.FlagSet: The flag set to register the flags on
+//
+// # Returns
+//
+// The probingFlags, which are populated once the flag set is parsed
+func addProbingFlags(flagSet *flag.FlagSet) *probingFlags {
+	defaults := defaultExperimentConfig()
+	flags := &probingFlags{flagSet: flagSet}
+	flags.numProbeStates = flagSet.Int("numProbeStates", defaults.States.NumProbeStates, "The number of probe states to use for each trial.")
+	flags.probeStatesFile = flagSet.String("probeStatesFile", "", "Path to the binary (gonumio) or .npy file containing the vector collection to use as probe states. If present, this method overrides random generation using numProbeStates.")
+	flags.numThreads = flagSet.Int("threads", defaults.Probing.Threads, "The number of threads to use for relaxation.")
+	flags.allowIntensiveDataCollection = flagSet.Bool("allowIntensiveDataCollection", defaults.DataCollection.RelaxationHistory, "Flag to allow data collection for very intensive methods, such as relaxationHistory")
+	flags.relaxationHistoryFlags = addRelaxationHistoryFlags(flagSet, defaults.DataCollection.RelaxationHistoryPolicy, "")
+	return flags
+}
+
+// Apply the probing flags given explicitly on the command line to an experiment configuration.
+func (flags *probingFlags) applyTo(config *ExperimentConfig) {
+	flags.flagSet.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "numProbeStates":
+			config.States.NumProbeStates = *flags.numProbeStates
+		case "probeStatesFile":
+			config.States.ProbeStatesFile = *flags.probeStatesFile
+		case "threads":
+			config.Probing.Threads = *flags.numThreads
+		case "allowIntensiveDataCollection":
+			config.DataCollection.RelaxationHistory = *flags.allowIntensiveDataCollection
+		}
+	})
+	flags.relaxationHistoryFlags.applyTo(&config.DataCollection.RelaxationHistoryPolicy, true)
+}
+
+// Register the relaxation history policy flags on a flag set.
+//
+// # Arguments
+//
+// flagSet *flag.FlagSet: The flag set to register the flags on
+//
+// defaults hopfieldnetwork.RelaxationHistoryPolicy: The policy giving the default values of the flags
+//
+// usageSuffix string: Text appended to the usage of each flag, e.g. to describe where the default comes from
+//
+// # Returns
+//
+// The relaxationHistoryFlags, which are populated once the flag set is parsed
+func addRelaxationHistoryFlags(flagSet *flag.FlagSet, defaults hopfieldnetwork.RelaxationHistoryPolicy, usageSuffix string) *relaxationHistoryFlags {
+	flags := &relaxationHistoryFlags{
+		flagSet:      flagSet,
+		probeIndices: defaults.ProbeIndices,
+	}
+	flags.stepInterval = flagSet.Int("historyStepInterval", defaults.StepInterval, "Record only every k-th step of the relaxation history. The initial and final steps are always recorded."+usageSuffix)
+	flags.sampleSize = flagSet.Int("historySampleSize", defaults.SampleSize, "Record the relaxation history of only a random sample of this many probe states. If 0, all probe states are recorded (unless historyProbeIndices is given)."+usageSuffix)
+	flagSet.Func("historyProbeIndices", "Comma separated indices of the probe states to record the relaxation history of, along with any sampled probe states."+usageSuffix, func(value string) error {
+		probeIndices, err := parseIntList(value)
+		flags.probeIndices = probeIndices
+		return err
+	})
+	flags.overlapsOnly = flagSet.Bool("historyOverlapsOnly", defaults.OverlapsOnly, "Record only the overlaps of each relaxation history step with the target states, rather than the full state and energy profile."+usageSuffix)
+	flags.spuriousOnly = flagSet.Bool("historySpuriousOnly", defaults.SpuriousOnly, "Record the relaxation history of only the probe states relaxing to a spurious state (not a target state or its inverse)."+usageSuffix)
+	return flags
+}
+
+// Apply the (parsed) relaxation history flags to a relaxation history policy.
+//
+// If onlySetFlags is true only the flags given explicitly on the command line are applied, so other values of the policy are kept.
+func (flags *relaxationHistoryFlags) applyTo(policy *hopfieldnetwork.RelaxationHistoryPolicy, onlySetFlags bool) {
+	applyFlag := func(f *flag.Flag) {
+		switch f.Name {
+		case "historyStepInterval":
+			policy.StepInterval = *flags.stepInterval
+		case "historySampleSize":
+			policy.SampleSize = *flags.sampleSize
+		case "historyProbeIndices":
+			policy.ProbeIndices = flags.probeIndices
+		case "historyOverlapsOnly":
+			policy.OverlapsOnly = *flags.overlapsOnly
+		case "historySpuriousOnly":
+			policy.SpuriousOnly = *flags.spuriousOnly
+		}
+	}
+	if onlySetFlags {
+		flags.flagSet.Visit(applyFlag)
+	} else {
+		flags.flagSet.VisitAll(applyFlag)
+	}
+}
+
+// Parse a comma separated list of integers, e.g. "1,5,10". An empty string is an empty list.
+func parseIntList(value string) ([]int, error) {
+	values := []int{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parsedItem, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, parsedItem)
+	}
+	return values, nil
+}
+
+// Register the data file flags on a flag set.
+//
+// # Arguments
+//
+// flagSet *flag.FlagSet: The flag set to register the flags on
+//
+// defaults DataCollectionConfig: The configuration giving the default values of the flags
+//
+// usageSuffix string: Text appended to the usage of each flag, e.g. to describe where the default comes from
+//
+// # Returns
+//
+// The dataFileFlags, which are populated once the flag set is parsed
+func addDataFileFlags(flagSet *flag.FlagSet, defaults DataCollectionConfig, usageSuffix string) *dataFileFlags {
+	flags := &dataFileFlags{flagSet: flagSet}
+	flagSet.TextVar(&flags.outputFormat, "outputFormat", defaults.OutputFormat, "The format of the data files, by name or integer value.\n0: ParquetFormat\n1: CSVFormat\n2: JSONLinesFormat"+usageSuffix)
+	flagSet.TextVar(&flags.parquetCompression, "parquetCompression", defaults.ParquetWriter.Compression, "The compression codec of parquet data files, by name or integer value.\n0: SnappyCompression\n1: GzipCompression\n2: ZstdCompression\n3: NoCompression"+usageSuffix)
+	flags.parquetRowGroupSizeMB = flagSet.Int64("parquetRowGroupSizeMB", defaults.ParquetWriter.RowGroupSizeMB, "The size of each row group of parquet data files, in megabytes."+usageSuffix)
+	flags.parquetPageSizeKB = flagSet.Int64("parquetPageSizeKB", defaults.ParquetWriter.PageSizeKB, "The size of each page of parquet data files, in kilobytes."+usageSuffix)
+	flags.parquetParallelism = flagSet.Int64("parquetParallelism", defaults.ParquetWriter.Parallelism, "The number of goroutines encoding each row group of parquet data files."+usageSuffix)
+	return flags
+}
+
+// Apply the (parsed) data file flags to a data collection configuration.
+//
+// If onlySetFlags is true only the flags given explicitly on the command line are applied, so other values of the configuration are kept.
+func (flags *dataFileFlags) applyTo(dataCollectionConfig *DataCollectionConfig, onlySetFlags bool) {
+	applyFlag := func(f *flag.Flag) {
+		switch f.Name {
+		case "outputFormat":
+			dataCollectionConfig.OutputFormat = flags.outputFormat
+		case "parquetCompression":
+			dataCollectionConfig.ParquetWriter.Compression = flags.parquetCompression
+		case "parquetRowGroupSizeMB":
+			dataCollectionConfig.ParquetWriter.RowGroupSizeMB = *flags.parquetRowGroupSizeMB
+		case "parquetPageSizeKB":
+			dataCollectionConfig.ParquetWriter.PageSizeKB = *flags.parquetPageSizeKB
+		case "parquetParallelism":
+			dataCollectionConfig.ParquetWriter.Parallelism = *flags.parquetParallelism
+		}
+	}
+	if onlySetFlags {
+		flags.flagSet.Visit(applyFlag)
+	} else {
+		flags.flagSet.VisitAll(applyFlag)
+	}
+}
+
+// Register the output flags on a flag set.
+//
+// # Arguments
+//
+// flagSet *flag.FlagSet: The flag set to register the flags on
+//
+// defaultDataDirectory string: The default data directory of the command
+//
+// # Returns
+//
+// The outputFlags, which are populated once the flag set is parsed
+func addOutputFlags(flagSet *flag.FlagSet, defaultDataDirectory string) *outputFlags {
+	return &outputFlags{
+		dataDirectory:   flagSet.String("dataDir", defaultDataDirectory, "The directory to store runs in. Each run writes to its own run directory within this directory."),
+		runName:         flagSet.String("runName", "", "The name of the run directory. If not given, a name is selected from the current time."),
+		overwrite:       flagSet.Bool("overwrite", false, "Allow an existing run directory to be replaced. Warning: Removes contents of the run directory!"),
+		logFilePath:     flagSet.String("logFile", "", "The file to write logs to. If not given, logs are written to log.txt in the run directory."),
+		verbose:         flagSet.Bool("verbose", false, "Verbose flag to print log messages to stdout."),
+		enableProfiling: flagSet.Bool("profile", false, "Enable profiling during this command."),
+	}
+}
+
+// Create the run directory described by the (parsed) output flags.
+//
+// If a run name is given the run directory is that name within the data directory. An existing run directory is
+// only replaced if overwrite is set, otherwise an error is returned. The run name must be a single plain path element
+// (not ".", ".." or containing a path separator) so only a directory within the data directory is ever replaced.
+//
+// If no run name is given, the run directory is named from the current time. Should that directory already exist
+// (e.g. two runs started within one second) a numbered suffix is added, so an existing run is never replaced.
+//
+// # Returns
+//
+// (path of the new, empty run directory, nil) on success, ("", error) on errors
+func (flags *outputFlags) createRunDirectory() (string, error) {
+	if runName := *flags.runName; runName != "" {
+		if runName == "." || runName == ".." || strings.ContainsAny(runName, `/\`) {
+			return "", fmt.Errorf("run name %#v must be a single directory name, without path separators", runName)
+		}
+	}
+	if err := os.MkdirAll(*flags.dataDirectory, 0700); err != nil {
+		return "", err
+	}
+
+	if *flags.runName != "" {
+		runDirectory := path.Join(*flags.dataDirectory, *flags.runName)
+		if _, err := os.Stat(runDirectory); err == nil {
+			if !*flags.overwrite {
+				return "", fmt.Errorf("run directory %#v already exists, use -overwrite to replace it", runDirectory)
+			}
+			if err := os.RemoveAll(runDirectory); err != nil {
+				return "", err
+			}
+		}
+		return runDirectory, os.Mkdir(runDirectory, 0700)
+	}
+
+	timestamp := time.Now().Format(RUN_DIRECTORY_TIME_FORMAT)
+	runDirectory := path.Join(*flags.dataDirectory, timestamp)
+	for suffix := 1; ; suffix++ {
+		// Mkdir fails if the directory exists, so concurrent runs can never share a directory
+		err := os.Mkdir(runDirectory, 0700)
+		if err == nil {
+			return runDirectory, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return "", err
+		}
+		runDirectory = path.Join(*flags.dataDirectory, fmt.Sprintf("%v-%d", timestamp, suffix))
+	}
+}
+
+// Create the logger described by the (parsed) output flags, writing to the run directory unless a log file is given.
+func (flags *outputFlags) newLogger(runDirectory string) (*log.Logger, error) {
+	logFilePath := *flags.logFilePath
+	if logFilePath == "" {
+		logFilePath = path.Join(runDirectory, LOG_SAVE_FILE)
+	}
+	return newLogger(logFilePath, *flags.verbose)
+}
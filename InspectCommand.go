@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+
+	"hmcalister/hopfield/hopfieldnetwork"
+	"hmcalister/hopfield/hopfieldnetwork/domain"
+)
+
+// Print the properties of a saved network, from the given command line arguments.
+//
+// The network is given either as a run directory (as written by the train or run command) or as a matrix file
+// with an optional target states file. Both binary (gonumio) and .npy files are accepted.
+func runInspectCommand(args []string) error {
+	inspectFlags := flag.NewFlagSet("inspect", flag.ExitOnError)
+	networkDirectory := inspectFlags.String("networkDir", "", "The run directory of the saved network to inspect, as written by the train or run command.")
+	matrixFile := inspectFlags.String("matrixFile", "", "Path to the binary (gonumio) or .npy file containing the weight matrix. Overrides the matrix of networkDir.")
+	targetStatesFile := inspectFlags.String("targetStatesFile", "", "Path to the binary (gonumio) or .npy file containing the target states. Overrides the target states of networkDir.")
+	var networkDomain domain.DomainEnum
+	inspectFlags.TextVar(&networkDomain, "domain", domain.BipolarDomain, "The network domain, by name or integer value. Defaults to the domain of networkDir.\n0: BipolarDomain\n1: BinaryDomain")
+	maximumUnstableUnits := inspectFlags.Int("maximumUnstableUnits", 0, "The number of unstable units allowed in a stable state. Defaults to the value of networkDir.")
+	inspectFlags.Parse(args)
+
+	if *networkDirectory != "" {
+		// Take the domain and stability threshold from the saved configuration, unless given explicitly
+		config := defaultExperimentConfig()
+		if err := config.LoadFile(path.Join(*networkDirectory, EXPERIMENT_CONFIG_SAVE_FILE)); err != nil {
+			return fmt.Errorf("saved network configuration loading failed: %w", err)
+		}
+		explicitFlags := map[string]bool{}
+		inspectFlags.Visit(func(f *flag.Flag) { explicitFlags[f.Name] = true })
+		if !explicitFlags["domain"] {
+			networkDomain = config.Network.Domain
+		}
+		if !explicitFlags["maximumUnstableUnits"] {
+			*maximumUnstableUnits = config.Network.MaximumRelaxationUnstableUnits
+		}
+
+		if *matrixFile == "" {
+			*matrixFile = path.Join(*networkDirectory, LEARNED_MATRIX_BINARY_SAVE_FILE)
+		}
+		if *targetStatesFile == "" {
+			*targetStatesFile = path.Join(*networkDirectory, TARGET_STATES_BINARY_SAVE_FILE)
+		}
+	}
+	if *matrixFile == "" {
+		return errors.New("one of networkDir or matrixFile must be given")
+	}
+
+	matrix, err := loadMatrix(*matrixFile)
+	if err != nil {
+		return fmt.Errorf("matrix loading failed: %w", err)
+	}
+	rows, cols := matrix.Dims()
+	if rows != cols {
+		return fmt.Errorf("matrix must be square, got %vx%v", rows, cols)
+	}
+
+	statistics := hopfieldnetwork.ComputeMatrixStatistics(matrix, 0)
+
+	fmt.Fprintf(os.Stdout, "Network %v\n", *matrixFile)
+	fmt.Fprintf(os.Stdout, "\tDomain:                   %v\n", networkDomain)
+	fmt.Fprintf(os.Stdout, "\tDimension:                %v\n", rows)
+	fmt.Fprintf(os.Stdout, "\tFrobenius Norm:           %.6g\n", statistics.FrobeniusNorm)
+	fmt.Fprintf(os.Stdout, "\tMaximum Weight:           %.6g\n", statistics.MaximumWeight)
+	fmt.Fprintf(os.Stdout, "\tMinimum Weight:           %.6g\n", statistics.MinimumWeight)
+	fmt.Fprintf(os.Stdout, "\tRelative Asymmetry:       %.6g\n", statistics.RelativeAsymmetry)
+	fmt.Fprintf(os.Stdout, "\tMaximum |Diagonal|:       %.6g\n", statistics.MaximumDiagonalMagnitude)
+
+	if *targetStatesFile == "" {
+		return nil
+	}
+	targetStates, err := loadVectorCollection(*targetStatesFile)
+	if err != nil {
+		return fmt.Errorf("target states loading failed: %w", err)
+	}
+
+	// Check the stability of each target state directly, in the same way as HopfieldNetwork.StateIsStable
+	domainManager := domain.GetDomainManager(networkDomain)
+	stableTargetStates := 0
+	for _, targetState := range targetStates {
+		if targetState.Len() != rows {
+			return fmt.Errorf("target states must have length %v, got %v", rows, targetState.Len())
+		}
+		unstableUnits := 0
+		for _, energy := range domainManager.AllUnitEnergies(matrix, targetState) {
+			if energy > 0 {
+				unstableUnits += 1
+			}
+		}
+		if unstableUnits <= *maximumUnstableUnits {
+			stableTargetStates += 1
+		}
+	}
+	fmt.Fprintf(os.Stdout, "\tStable Target States:     %v / %v\n", stableTargetStates, len(targetStates))
+	return nil
+}
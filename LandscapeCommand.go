@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path"
+
+	"hmcalister/hopfield/hopfieldnetwork"
+	"hmcalister/hopfield/hopfieldnetwork/datacollector"
+)
+
+const (
+	LANDSCAPE_MINIMA_SAVE_FILE  = "landscapeMinima.pq"
+	LANDSCAPE_TARGETS_SAVE_FILE = "landscapeTargets.pq"
+)
+
+// Enumerate the full energy landscape of a small saved network, from the given command line arguments.
+//
+// The energy of all 2^dimension states is computed, every local minimum under single unit flips is found, and
+// the exact basin size of each minimum is measured by following the steepest descent of every state.
+// See hopfieldnetwork.EnumerateEnergyLandscape. Only networks up to hopfieldnetwork.MAXIMUM_LANDSCAPE_DIMENSION can be enumerated.
+//
+// The minima (classified by their relation to the target states) are written to landscapeMinima.pq, and the
+// minimum each target state descends to is written to landscapeTargets.pq.
+func runLandscapeCommand(args []string) error {
+	landscapeFlags := flag.NewFlagSet("landscape", flag.ExitOnError)
+	networkDirectory := landscapeFlags.String("networkDir", "", "The run directory of the saved network, as written by the train or run command. Required.")
+	numThreads := landscapeFlags.Int("threads", 1, "The number of threads to enumerate states with. Defaults to the value of the saved network.")
+	dataFileFlags := addDataFileFlags(landscapeFlags, defaultExperimentConfig().DataCollection, "\nDefaults to the value of the saved network.")
+	outputFlags := addOutputFlags(landscapeFlags, "data/hopfieldLandscape")
+	landscapeFlags.Parse(args)
+
+	if *networkDirectory == "" {
+		return errors.New("networkDir must be given")
+	}
+
+	config, matrix, targetStates, _, err := loadSavedNetwork(*networkDirectory)
+	if err != nil {
+		return err
+	}
+	landscapeFlags.Visit(func(f *flag.Flag) {
+		if f.Name == "threads" {
+			config.Probing.Threads = *numThreads
+		}
+	})
+	dataFileFlags.applyTo(&config.DataCollection, true)
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if config.Network.Dimension > hopfieldnetwork.MAXIMUM_LANDSCAPE_DIMENSION {
+		return fmt.Errorf("the landscape of a network can only be enumerated up to dimension %d, got %d", hopfieldnetwork.MAXIMUM_LANDSCAPE_DIMENSION, config.Network.Dimension)
+	}
+
+	defer startProfiling(*outputFlags.enableProfiling)()
+
+	runDirectory, err := outputFlags.createRunDirectory()
+	if err != nil {
+		return err
+	}
+	logger, err := outputFlags.newLogger(runDirectory)
+	if err != nil {
+		return fmt.Errorf("could not open log file: %w", err)
+	}
+	logger.Printf("Writing to run directory %#v\n", runDirectory)
+
+	// ENUMERATE LANDSCAPE ------------------------------------------------------------------------
+	logger.SetPrefix("Landscape Enumeration: ")
+	landscape, err := hopfieldnetwork.EnumerateEnergyLandscape(matrix, config.Network.Domain, config.Probing.Threads)
+	if err != nil {
+		return err
+	}
+	numStates := 1 << config.Network.Dimension
+
+	minimumData := make([]*datacollector.LandscapeMinimumData, len(landscape.Minima))
+	for minimumIndex, minimum := range landscape.Minima {
+		attractorClassification := hopfieldnetwork.ClassifyAttractor(minimum.State, targetStates, config.Network.Domain)
+		minimumData[minimumIndex] = &datacollector.LandscapeMinimumData{
+			StateIndex:     minimum.StateIndex,
+			State:          minimum.State.RawVector().Data,
+			Energy:         minimum.Energy,
+			BasinSize:      minimum.BasinSize,
+			BasinFraction:  float64(minimum.BasinSize) / float64(numStates),
+			AttractorClass: attractorClassification.Class.String(),
+			MixtureTargets: attractorClassification.MixtureTargets,
+			MixtureSigns:   attractorClassification.MixtureSigns,
+		}
+	}
+
+	targetData := make([]*datacollector.LandscapeTargetData, len(targetStates))
+	targetMinima := map[int]bool{}
+	targetBasinStates := 0
+	for targetIndex, targetState := range targetStates {
+		stateIndex := landscape.StateIndex(targetState)
+		minimumStateIndex := landscape.DescendToMinimum(stateIndex)
+		basinSize := 0
+		for _, minimum := range landscape.Minima {
+			if minimum.StateIndex == minimumStateIndex {
+				basinSize = minimum.BasinSize
+				break
+			}
+		}
+		targetData[targetIndex] = &datacollector.LandscapeTargetData{
+			TargetIndex:       targetIndex,
+			StateIndex:        stateIndex,
+			Energy:            landscape.Energy(stateIndex),
+			IsMinimum:         landscape.IsMinimum(stateIndex),
+			MinimumStateIndex: minimumStateIndex,
+			BasinSize:         basinSize,
+		}
+		if landscape.IsMinimum(stateIndex) && !targetMinima[stateIndex] {
+			targetMinima[stateIndex] = true
+			targetBasinStates += basinSize
+		}
+	}
+	logger.Printf("Found %v local minima in %v states\n", len(landscape.Minima), numStates)
+	logger.Printf("%v of %v target states are local minima, with basins covering %.4f of all states\n",
+		len(targetMinima), len(targetStates), float64(targetBasinStates)/float64(numStates))
+
+	// CLEAN UP & FINISH --------------------------------------------------------------------------
+	logger.SetPrefix("Clean Up: ")
+	outputFormat := config.DataCollection.OutputFormat
+	if err := datacollector.WriteLandscapeMinima(path.Join(runDirectory, dataFileName(LANDSCAPE_MINIMA_SAVE_FILE, outputFormat)), config.DataCollection.DataFileSettings(), minimumData); err != nil {
+		return fmt.Errorf("landscape minima saving failed: %w", err)
+	}
+	if err := datacollector.WriteLandscapeTargets(path.Join(runDirectory, dataFileName(LANDSCAPE_TARGETS_SAVE_FILE, outputFormat)), config.DataCollection.DataFileSettings(), targetData); err != nil {
+		return fmt.Errorf("landscape targets saving failed: %w", err)
+	}
+	if err := config.WriteFile(path.Join(runDirectory, EXPERIMENT_CONFIG_SAVE_FILE)); err != nil {
+		return fmt.Errorf("configuration saving failed: %w", err)
+	}
+
+	manifestConfiguration := struct {
+		Experiment       *ExperimentConfig `json:"experiment"`
+		NetworkDirectory string            `json:"networkDirectory"`
+	}{config, *networkDirectory}
+	if err := writeManifest(runDirectory, "landscape", args, manifestConfiguration); err != nil {
+		return fmt.Errorf("could not write manifest: %w", err)
+	}
+
+	logger.Println("DONE")
+	return nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"hmcalister/hopfield/hopfieldnetwork/datacollector"
+	"hmcalister/hopfield/hopfieldutils/npyio"
+)
+
+const MANIFEST_SAVE_FILE = "manifest.json"
+
+// A record of a run, written to the run directory so the data can be understood without the command that produced it.
+//
+// ProgramVersion is the version of this program, independent of any version control.
+// Configuration is the full resolved configuration of the run (an ExperimentConfig, or a SweepConfig for a sweep).
+type Manifest struct {
+	ProgramVersion string          `json:"programVersion"`
+	GoVersion      string          `json:"goVersion"`
+	Command        string          `json:"command"`
+	Arguments      []string        `json:"arguments"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	Configuration  json.RawMessage `json:"configuration"`
+	Files          []ManifestFile  `json:"files"`
+}
+
+// A single file produced by a run.
+//
+// Path is relative to the run directory.
+// Rows is given for data files (parquet, CSV and JSON Lines files), RowGroups, Compression, and Schema only for parquet files,
+// and Shape only for NumPy files.
+type ManifestFile struct {
+	Path        string                        `json:"path"`
+	Format      string                        `json:"format"`
+	Bytes       int64                         `json:"bytes"`
+	Rows        *int64                        `json:"rows,omitempty"`
+	RowGroups   *int                          `json:"rowGroups,omitempty"`
+	Compression string                        `json:"compression,omitempty"`
+	Schema      []datacollector.ParquetColumn `json:"schema,omitempty"`
+	Shape       []int                         `json:"shape,omitempty"`
+}
+
+// Write the manifest of a run, listing every file in the run directory (including subdirectories).
+//
+// This should be called once all other files are written, as the files are only listed, not watched.
+//
+// # Arguments
+//
+// runDirectory string: The run directory to write the manifest to
+//
+// command string: The command that produced the run
+//
+// args []string: The command line arguments given to that command
+//
+// configuration interface{}: The full resolved configuration of the run, which must marshal to JSON
+func writeManifest(runDirectory string, command string, args []string, configuration interface{}) error {
+	configurationJSON, err := json.Marshal(configuration)
+	if err != nil {
+		return err
+	}
+	manifest := &Manifest{
+		ProgramVersion: PROGRAM_VERSION,
+		GoVersion:      runtime.Version(),
+		Command:        command,
+		Arguments:      args,
+		CreatedAt:      time.Now(),
+		Configuration:  configurationJSON,
+	}
+	return manifest.writeFile(runDirectory)
+}
+
+// Update the file list of the manifest in a run directory, for commands (such as analyze) that add files to an existing run.
+//
+// If the run directory has no manifest nothing is done.
+func refreshManifest(runDirectory string) error {
+	manifestFile, err := os.ReadFile(filepath.Join(runDirectory, MANIFEST_SAVE_FILE))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	manifest := &Manifest{}
+	if err := json.Unmarshal(manifestFile, manifest); err != nil {
+		return err
+	}
+	return manifest.writeFile(runDirectory)
+}
+
+// List the files of the run directory and write the manifest to it.
+func (manifest *Manifest) writeFile(runDirectory string) error {
+	files, err := listManifestFiles(runDirectory)
+	if err != nil {
+		return err
+	}
+	manifest.Files = files
+
+	manifestFile, err := json.MarshalIndent(manifest, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(runDirectory, MANIFEST_SAVE_FILE), manifestFile, 0600)
+}
+
+// Describe every file in a run directory (except the top level manifest itself), in lexical order.
+func listManifestFiles(runDirectory string) ([]ManifestFile, error) {
+	files := []ManifestFile{}
+	err := filepath.WalkDir(runDirectory, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		relativePath, err := filepath.Rel(runDirectory, filePath)
+		if err != nil {
+			return err
+		}
+		if relativePath == MANIFEST_SAVE_FILE {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		file := ManifestFile{
+			Path:  filepath.ToSlash(relativePath),
+			Bytes: info.Size(),
+		}
+		switch strings.ToLower(filepath.Ext(filePath)) {
+		case ".pq":
+			file.Format = "parquet"
+			metadata, err := datacollector.ReadParquetMetadata(filePath)
+			if err != nil {
+				return err
+			}
+			file.Schema = metadata.Columns
+			file.Rows = &metadata.Rows
+			file.RowGroups = &metadata.RowGroups
+			file.Compression = metadata.Compression
+		case npyio.NPY_FILE_EXTENSION:
+			file.Format = "npy"
+			shape, err := npyio.LoadShape(filePath)
+			if err != nil {
+				return err
+			}
+			file.Shape = shape
+		case npyio.NPZ_FILE_EXTENSION:
+			file.Format = "npz"
+		case ".bin":
+			file.Format = "gonumio"
+		case ".csv":
+			file.Format = "csv"
+			rows, err := datacollector.CountDataFileRows(filePath, datacollector.CSVFormat)
+			if err != nil {
+				return err
+			}
+			file.Rows = &rows
+		case ".jsonl":
+			file.Format = "jsonl"
+			rows, err := datacollector.CountDataFileRows(filePath, datacollector.JSONLinesFormat)
+			if err != nil {
+				return err
+			}
+			file.Rows = &rows
+		case ".json":
+			file.Format = "json"
+		case ".txt":
+			file.Format = "text"
+		default:
+			file.Format = "unknown"
+		}
+		files = append(files, file)
+		return nil
+	})
+	return files, err
+}
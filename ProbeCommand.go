@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path"
+
+	"github.com/hmcalister/gonum-matrix-io/pkg/gonumio"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Probe a network previously saved by the train (or run) command, from the given command line arguments.
+//
+// The configuration of the network is read from the network directory. Flags given explicitly on the command line
+// (such as -numProbeStates or -seed) override the saved configuration, all other flags are ignored.
+//
+// If the seed is unchanged the probe states are generated exactly as in the run command, so a trained network
+// is probed with the same states a single run with the same configuration would use.
+func runProbeCommand(args []string) error {
+	probeFlags := flag.NewFlagSet("probe", flag.ExitOnError)
+	networkDirectory := probeFlags.String("networkDir", "", "The run directory of the saved network to probe, as written by the train or run command. Required.")
+	seed := probeFlags.Uint64("seed", 0, "The seed for the random generators of the network and states. Defaults to the seed of the saved network.")
+	numProbeStates := probeFlags.Int("numProbeStates", 1000, "The number of probe states to use. Defaults to the value of the saved network.")
+	probeStatesFile := probeFlags.String("probeStatesFile", "", "Path to the binary (gonumio) or .npy file containing the vector collection to use as probe states. If present, this method overrides random generation using numProbeStates.")
+	numThreads := probeFlags.Int("threads", 1, "The number of threads to use for relaxation. Defaults to the value of the saved network.")
+	allowIntensiveDataCollection := probeFlags.Bool("allowIntensiveDataCollection", false, "Flag to allow data collection for very intensive methods, such as relaxationHistory")
+	relaxationHistoryFlags := addRelaxationHistoryFlags(probeFlags, defaultExperimentConfig().DataCollection.RelaxationHistoryPolicy, "\nDefaults to the value of the saved network.")
+	dataFileFlags := addDataFileFlags(probeFlags, defaultExperimentConfig().DataCollection, "\nDefaults to the value of the saved network.")
+	outputFlags := addOutputFlags(probeFlags, "data/hopfieldProbe")
+	probeFlags.Parse(args)
+
+	if *networkDirectory == "" {
+		return errors.New("networkDir must be given")
+	}
+
+	// Load the saved network, then apply any flags given explicitly
+	config, matrix, targetStates, targetStatesGenerated, err := loadSavedNetwork(*networkDirectory)
+	if err != nil {
+		return err
+	}
+	// Probing data is collected by this command and learning data was collected by the train command
+	defaults := defaultExperimentConfig()
+	config.DataCollection.RelaxationResult = defaults.DataCollection.RelaxationResult
+	config.DataCollection.UniqueRelaxedStates = defaults.DataCollection.UniqueRelaxedStates
+	config.DataCollection.LearnState = false
+	config.DataCollection.LearnEpoch = false
+	config.DataCollection.MatrixSnapshotInterval = 0
+	config.DataCollection.Spectrum = false
+	config.DataCollection.TargetStateProbe = false
+	probeFlags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "seed":
+			config.Seed = *seed
+		case "numProbeStates":
+			config.States.NumProbeStates = *numProbeStates
+		case "probeStatesFile":
+			config.States.ProbeStatesFile = *probeStatesFile
+		case "threads":
+			config.Probing.Threads = *numThreads
+		case "allowIntensiveDataCollection":
+			config.DataCollection.RelaxationHistory = *allowIntensiveDataCollection
+		}
+	})
+	relaxationHistoryFlags.applyTo(&config.DataCollection.RelaxationHistoryPolicy, true)
+	dataFileFlags.applyTo(&config.DataCollection, true)
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	defer startProfiling(*outputFlags.enableProfiling)()
+
+	runDirectory, err := outputFlags.createRunDirectory()
+	if err != nil {
+		return err
+	}
+	logger, err := outputFlags.newLogger(runDirectory)
+	if err != nil {
+		return fmt.Errorf("could not open log file: %w", err)
+	}
+	logger.Printf("Writing to run directory %#v\n", runDirectory)
+
+	collector, err := newDataCollector(config, runDirectory)
+	if err != nil {
+		return err
+	}
+	collector.Start()
+	// Close is called explicitly on success to report errors, this ensures the data is written on failure too
+	defer collector.Close()
+
+	network := newNetworkBuilder(config, collector, logger).
+		SetMatrix(matrix).
+		SetTargetStates(targetStates).
+		Build()
+
+	// Skip over the randomly generated target states, so the probe states match those of the run command
+	stateGenerator := newStateGenerator(config)
+	if targetStatesGenerated {
+		stateGenerator.CreateStateCollection(config.States.NumTargetStates)
+	}
+
+	trialResult := &TrialResult{
+		TargetStates: len(targetStates),
+	}
+	if err := probeNetwork(config, network, stateGenerator, runDirectory, trialResult, logger); err != nil {
+		return err
+	}
+	logger.Printf("%v of %v probe states relaxed to a stable state\n", trialResult.StableProbeStates, trialResult.ProbeStates)
+
+	// CLEAN UP & FINISH --------------------------------------------------------------------------
+	logger.SetPrefix("Clean Up: ")
+	if err := writeTrialRecord(config, network, runDirectory); err != nil {
+		return err
+	}
+	if err := collector.Close(); err != nil {
+		return err
+	}
+
+	if err := writeManifest(runDirectory, "probe", args, config); err != nil {
+		return fmt.Errorf("could not write manifest: %w", err)
+	}
+
+	logger.Println("DONE")
+	return nil
+}
+
+// Load the configuration, weight matrix and target states of a network saved by the train (or run) command.
+//
+// The target states are always loaded from the network directory, so the target states file of the saved configuration
+// (which may be a relative path from where the network was trained) is cleared, and the number of target states is set
+// to the number loaded.
+//
+// # Arguments
+//
+// networkDirectory string: The run directory of the saved network
+//
+// # Returns
+//
+// (config, matrix, target states, targetStatesGenerated, nil) on success, or an error if any of the saved files could not be loaded.
+// targetStatesGenerated is true if the target states were randomly generated (rather than loaded from a file) when the network was trained.
+func loadSavedNetwork(networkDirectory string) (*ExperimentConfig, *mat.Dense, []*mat.VecDense, bool, error) {
+	config := defaultExperimentConfig()
+	if err := config.LoadFile(path.Join(networkDirectory, EXPERIMENT_CONFIG_SAVE_FILE)); err != nil {
+		return nil, nil, nil, false, fmt.Errorf("saved network configuration loading failed: %w", err)
+	}
+	matrix, err := gonumio.LoadMatrix(path.Join(networkDirectory, LEARNED_MATRIX_BINARY_SAVE_FILE))
+	if err != nil {
+		return nil, nil, nil, false, fmt.Errorf("saved network matrix loading failed: %w", err)
+	}
+	targetStates, err := gonumio.LoadVectorCollection(path.Join(networkDirectory, TARGET_STATES_BINARY_SAVE_FILE))
+	if err != nil {
+		return nil, nil, nil, false, fmt.Errorf("saved network target states loading failed: %w", err)
+	}
+
+	targetStatesGenerated := config.States.TargetStatesFile == ""
+	config.States.TargetStatesFile = ""
+	config.States.NumTargetStates = len(targetStates)
+	return config, matrix, targetStates, targetStatesGenerated, nil
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hmcalister/hopfield/hopfieldnetwork"
+	"hmcalister/hopfield/hopfieldnetwork/datacollector"
+)
+
+const (
+	SWEEP_CONFIG_SAVE_FILE = "sweepConfig.json"
+	SWEEP_INDEX_SAVE_FILE  = "sweepIndex.pq"
+)
+
+// A description of a parameter sweep.
+//
+// Every combination of the parameter grids is run Repeats times, each with a distinct seed, using Base for all other settings.
+// An empty grid leaves the value from Base unchanged.
+//
+// Seed is the base seed of the sweep, from which the seed of each trial is derived. A seed of 0 selects a seed from the current time.
+type SweepConfig struct {
+	Base       ExperimentConfig `json:"base" yaml:"base"`
+	Parameters SweepParameters  `json:"parameters" yaml:"parameters"`
+	Repeats    int              `json:"repeats" yaml:"repeats"`
+	Seed       uint64           `json:"seed" yaml:"seed"`
+}
+
+// The parameter grids of a sweep.
+type SweepParameters struct {
+	Dimension          []int                              `json:"dimension" yaml:"dimension"`
+	NumTargetStates    []int                              `json:"numTargetStates" yaml:"numTargetStates"`
+	LearningRule       []hopfieldnetwork.LearningRuleEnum `json:"learningRule" yaml:"learningRule"`
+	LearningNoiseScale []float64                          `json:"learningNoiseScale" yaml:"learningNoiseScale"`
+}
+
+// A single trial of a sweep, ready to be run by a worker.
+type sweepTrial struct {
+	indexData *datacollector.SweepIndexData
+	config    *ExperimentConfig
+}
+
+// Load a SweepConfig from a JSON or YAML file, overwriting only the fields present in that file.
+func (sweepConfig *SweepConfig) LoadFile(sweepConfigFilePath string) error {
+	return decodeConfigFile(sweepConfigFilePath, sweepConfig)
+}
+
+// Write the SweepConfig to a file as JSON, so the resolved sweep (including the seed) is recorded alongside the data.
+func (sweepConfig *SweepConfig) WriteFile(sweepConfigFilePath string) error {
+	return writeConfigFile(sweepConfigFilePath, sweepConfig)
+}
+
+// Expand the sweep into the individual trials, in a deterministic order.
+//
+// Each trial is validated, so an invalid combination of parameters is reported before any trial is run.
+func (sweepConfig *SweepConfig) expandTrials() ([]*sweepTrial, error) {
+	if sweepConfig.Repeats <= 0 {
+		return nil, fmt.Errorf("repeats must be a positive integer, got %d", sweepConfig.Repeats)
+	}
+
+	// Empty grids take the single value from the base configuration
+	dimensions := sweepConfig.Parameters.Dimension
+	if len(dimensions) == 0 {
+		dimensions = []int{sweepConfig.Base.Network.Dimension}
+	}
+	numTargetStates := sweepConfig.Parameters.NumTargetStates
+	if len(numTargetStates) == 0 {
+		numTargetStates = []int{sweepConfig.Base.States.NumTargetStates}
+	}
+	learningRules := sweepConfig.Parameters.LearningRule
+	if len(learningRules) == 0 {
+		learningRules = []hopfieldnetwork.LearningRuleEnum{sweepConfig.Base.Learning.Rule}
+	}
+	learningNoiseScales := sweepConfig.Parameters.LearningNoiseScale
+	if len(learningNoiseScales) == 0 {
+		learningNoiseScales = []float64{sweepConfig.Base.Learning.NoiseScale}
+	}
+
+	trials := []*sweepTrial{}
+	for _, dimension := range dimensions {
+		for _, numTargets := range numTargetStates {
+			for _, learningRule := range learningRules {
+				for _, learningNoiseScale := range learningNoiseScales {
+					for repeat := 0; repeat < sweepConfig.Repeats; repeat++ {
+						trialIndex := len(trials)
+						// Each trial uses two consecutive seeds (network and state generator) so we step by two
+						trialSeed := sweepConfig.Seed + 2*uint64(trialIndex) + 1
+
+						trialConfig := sweepConfig.Base
+						trialConfig.Seed = trialSeed
+						trialConfig.Network.Dimension = dimension
+						trialConfig.States.NumTargetStates = numTargets
+						trialConfig.Learning.Rule = learningRule
+						trialConfig.Learning.NoiseScale = learningNoiseScale
+						if err := trialConfig.Validate(); err != nil {
+							return nil, fmt.Errorf("trial %d: %w", trialIndex, err)
+						}
+
+						trials = append(trials, &sweepTrial{
+							indexData: &datacollector.SweepIndexData{
+								TrialIndex:         trialIndex,
+								TrialDirectory:     fmt.Sprintf("trial%05d", trialIndex),
+								Repeat:             repeat,
+								Seed:               int64(trialSeed),
+								NetworkDimension:   dimension,
+								TargetStates:       numTargets,
+								LearningRule:       learningRule.String(),
+								LearningNoiseScale: learningNoiseScale,
+							},
+							config: &trialConfig,
+						})
+					}
+				}
+			}
+		}
+	}
+	return trials, nil
+}
+
+// Run a parameter sweep from the command line arguments following the "sweep" subcommand.
+//
+// Trials are run in parallel by a bounded pool of workers, each trial writing to its own subdirectory of the data directory.
+// Once all trials are complete an index of all trials (keyed by the swept parameters) is written to the data directory.
+func runSweepCommand(args []string) error {
+	sweepFlags := flag.NewFlagSet("sweep", flag.ExitOnError)
+	sweepConfigFilePath := sweepFlags.String("config", "", "Path to a JSON (.json) or YAML (.yaml, .yml) sweep configuration file, holding a base experiment and parameter grids.")
+	dimensionGrid := sweepFlags.String("dimension", "", "Comma separated network dimensions to sweep over. Overrides the grid in the sweep configuration.")
+	numTargetStatesGrid := sweepFlags.String("numTargetStates", "", "Comma separated numbers of target states to sweep over. Overrides the grid in the sweep configuration.")
+	learningRuleGrid := sweepFlags.String("learningRule", "", "Comma separated learning rules (by name or integer value) to sweep over. Overrides the grid in the sweep configuration.")
+	learningNoiseScaleGrid := sweepFlags.String("learningNoiseScale", "", "Comma separated learning noise scales to sweep over. Overrides the grid in the sweep configuration.")
+	repeats := sweepFlags.Int("repeats", 0, "The number of repeats of each combination of parameters. Overrides the value in the sweep configuration (default 1).")
+	sweepSeed := sweepFlags.Uint64("seed", 0, "The base seed of the sweep. Overrides the value in the sweep configuration. If 0, a seed is selected from the current time.")
+	numWorkers := sweepFlags.Int("workers", runtime.NumCPU(), "The number of trials to run in parallel.")
+	outputFlags := addOutputFlags(sweepFlags, "data/hopfieldSweep")
+	sweepFlags.Parse(args)
+
+	// The base experiment defaults to the same values as a single trial
+	sweepConfig := &SweepConfig{
+		Base:    *defaultExperimentConfig(),
+		Repeats: 1,
+	}
+	if *sweepConfigFilePath != "" {
+		if err := sweepConfig.LoadFile(*sweepConfigFilePath); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if *dimensionGrid != "" {
+		if sweepConfig.Parameters.Dimension, err = parseGrid(*dimensionGrid, strconv.Atoi); err != nil {
+			return fmt.Errorf("could not parse dimension grid: %w", err)
+		}
+	}
+	if *numTargetStatesGrid != "" {
+		if sweepConfig.Parameters.NumTargetStates, err = parseGrid(*numTargetStatesGrid, strconv.Atoi); err != nil {
+			return fmt.Errorf("could not parse numTargetStates grid: %w", err)
+		}
+	}
+	if *learningRuleGrid != "" {
+		if sweepConfig.Parameters.LearningRule, err = parseGrid(*learningRuleGrid, hopfieldnetwork.ParseLearningRuleEnum); err != nil {
+			return fmt.Errorf("could not parse learningRule grid: %w", err)
+		}
+	}
+	if *learningNoiseScaleGrid != "" {
+		parseFloat := func(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+		if sweepConfig.Parameters.LearningNoiseScale, err = parseGrid(*learningNoiseScaleGrid, parseFloat); err != nil {
+			return fmt.Errorf("could not parse learningNoiseScale grid: %w", err)
+		}
+	}
+	if *repeats != 0 {
+		sweepConfig.Repeats = *repeats
+	}
+	if *sweepSeed != 0 {
+		sweepConfig.Seed = *sweepSeed
+	}
+	if sweepConfig.Seed == 0 {
+		sweepConfig.Seed = uint64(time.Now().UnixNano())
+	}
+	if *numWorkers <= 0 {
+		return fmt.Errorf("workers must be a positive integer, got %d", *numWorkers)
+	}
+
+	trials, err := sweepConfig.expandTrials()
+	if err != nil {
+		return err
+	}
+
+	defer startProfiling(*outputFlags.enableProfiling)()
+
+	sweepDataDirectory, err := outputFlags.createRunDirectory()
+	if err != nil {
+		return err
+	}
+	// Each trial also writes a log to its own directory
+	logger, err := outputFlags.newLogger(sweepDataDirectory)
+	if err != nil {
+		return fmt.Errorf("could not open log file: %w", err)
+	}
+	logger.Printf("Writing to sweep directory %#v\n", sweepDataDirectory)
+
+	// Record the resolved sweep configuration, including the seed, so the sweep can be repeated exactly
+	if err := sweepConfig.WriteFile(path.Join(sweepDataDirectory, SWEEP_CONFIG_SAVE_FILE)); err != nil {
+		return err
+	}
+
+	logger.Printf("Running %d trials with %d workers\n", len(trials), *numWorkers)
+	trialChannel := make(chan *sweepTrial)
+	var workerGroup sync.WaitGroup
+	for workerIndex := 0; workerIndex < *numWorkers; workerIndex++ {
+		workerGroup.Add(1)
+		go func() {
+			defer workerGroup.Done()
+			for trial := range trialChannel {
+				runSweepTrial(trial, sweepDataDirectory)
+				if trial.indexData.Error != "" {
+					logger.Printf("Trial %d failed: %v\n", trial.indexData.TrialIndex, trial.indexData.Error)
+				} else {
+					logger.Printf("Finished trial %d\n", trial.indexData.TrialIndex)
+				}
+			}
+		}()
+	}
+	for _, trial := range trials {
+		trialChannel <- trial
+	}
+	close(trialChannel)
+	workerGroup.Wait()
+
+	indexData := make([]*datacollector.SweepIndexData, len(trials))
+	failedTrials := 0
+	for trialIndex, trial := range trials {
+		indexData[trialIndex] = trial.indexData
+		if trial.indexData.Error != "" {
+			failedTrials += 1
+		}
+	}
+	sweepIndexFile := path.Join(sweepDataDirectory, dataFileName(SWEEP_INDEX_SAVE_FILE, sweepConfig.Base.DataCollection.OutputFormat))
+	if err := datacollector.WriteSweepIndex(sweepIndexFile, sweepConfig.Base.DataCollection.DataFileSettings(), indexData); err != nil {
+		return fmt.Errorf("could not write sweep index: %w", err)
+	}
+
+	if err := writeManifest(sweepDataDirectory, "sweep", args, sweepConfig); err != nil {
+		return fmt.Errorf("could not write manifest: %w", err)
+	}
+
+	logger.Printf("Sweep finished, %d of %d trials failed\n", failedTrials, len(trials))
+	if failedTrials > 0 {
+		return fmt.Errorf("%d of %d trials failed, see %v for details", failedTrials, len(trials), sweepIndexFile)
+	}
+	return nil
+}
+
+// Run a single trial of a sweep, recording the outcome in the index data of the trial.
+func runSweepTrial(trial *sweepTrial, sweepDataDirectory string) {
+	trialDataDirectory := path.Join(sweepDataDirectory, trial.indexData.TrialDirectory)
+	if err := os.MkdirAll(trialDataDirectory, 0700); err != nil {
+		trial.indexData.Error = err.Error()
+		return
+	}
+
+	trialLogger, err := newLogger(path.Join(trialDataDirectory, LOG_SAVE_FILE), false)
+	if err != nil {
+		trial.indexData.Error = err.Error()
+		return
+	}
+
+	trialResult, err := runTrial(trial.config, trialDataDirectory, trialLogger)
+	if err != nil {
+		trial.indexData.Error = err.Error()
+		return
+	}
+	if err := writeManifest(trialDataDirectory, "sweep", nil, trial.config); err != nil {
+		trial.indexData.Error = err.Error()
+		return
+	}
+	trial.indexData.TargetStates = trialResult.TargetStates
+	trial.indexData.StableTargetStates = trialResult.StableTargetStates
+	trial.indexData.ProbeStates = trialResult.ProbeStates
+	trial.indexData.StableProbeStates = trialResult.StableProbeStates
+}
+
+// Parse a comma separated list of values into a slice, using the given function to parse each value.
+func parseGrid[T any](grid string, parseValue func(string) (T, error)) ([]T, error) {
+	values := []T{}
+	for _, valueString := range strings.Split(grid, ",") {
+		value, err := parseValue(strings.TrimSpace(valueString))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+// Learn a network and save it (along with the learning data) without probing, from the given command line arguments.
+//
+// The saved network can later be probed with the probe command, pointing -networkDir at the run directory of this command.
+func runTrainCommand(args []string) error {
+	trainFlags := flag.NewFlagSet("train", flag.ExitOnError)
+	experimentFlags := addExperimentFlags(trainFlags)
+	outputFlags := addOutputFlags(trainFlags, "data/hopfieldTrain")
+	trainFlags.Parse(args)
+
+	config, err := experimentFlags.resolveConfig(nil)
+	if err != nil {
+		return err
+	}
+	// Probing is done by the probe command, so only learning data is collected
+	config.DataCollection.RelaxationResult = false
+	config.DataCollection.UniqueRelaxedStates = false
+	config.DataCollection.RelaxationHistory = false
+
+	defer startProfiling(*outputFlags.enableProfiling)()
+
+	runDirectory, err := outputFlags.createRunDirectory()
+	if err != nil {
+		return err
+	}
+	logger, err := outputFlags.newLogger(runDirectory)
+	if err != nil {
+		return fmt.Errorf("could not open log file: %w", err)
+	}
+	logger.Printf("Writing to run directory %#v\n", runDirectory)
+
+	collector, err := newDataCollector(config, runDirectory)
+	if err != nil {
+		return err
+	}
+	collector.Start()
+	// Close is called explicitly on success to report errors, this ensures the data is written on failure too
+	defer collector.Close()
+
+	network := newNetworkBuilder(config, collector, logger).Build()
+	stateGenerator := newStateGenerator(config)
+	trialResult, err := trainNetwork(config, network, stateGenerator, runDirectory, logger)
+	if err != nil {
+		return err
+	}
+	logger.Printf("%v of %v target states are stable\n", trialResult.StableTargetStates, trialResult.TargetStates)
+
+	// CLEAN UP & FINISH --------------------------------------------------------------------------
+	logger.SetPrefix("Clean Up: ")
+	if err := writeTrialRecord(config, network, runDirectory); err != nil {
+		return err
+	}
+	if err := collector.Close(); err != nil {
+		return err
+	}
+
+	if err := writeManifest(runDirectory, "train", args, config); err != nil {
+		return fmt.Errorf("could not write manifest: %w", err)
+	}
+
+	logger.Println("DONE")
+	return nil
+}
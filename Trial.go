@@ -0,0 +1,439 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/hmcalister/gonum-matrix-io/pkg/gonumio"
+	"gonum.org/v1/gonum/mat"
+
+	"hmcalister/hopfield/hopfieldnetwork"
+	"hmcalister/hopfield/hopfieldnetwork/datacollector"
+	"hmcalister/hopfield/hopfieldnetwork/spectralanalysis"
+	states "hmcalister/hopfield/hopfieldnetwork/states"
+	"hmcalister/hopfield/hopfieldutils/npyio"
+)
+
+const (
+	LEARNED_MATRIX_BINARY_SAVE_FILE = "matrix.bin"
+	TARGET_STATES_BINARY_SAVE_FILE  = "targetStates.bin"
+	LEARNED_MATRIX_NPY_SAVE_FILE    = "matrix.npy"
+	TARGET_STATES_NPY_SAVE_FILE     = "targetStates.npy"
+	EXPERIMENT_CONFIG_SAVE_FILE     = "experimentConfig.json"
+	NETWORK_SUMMARY_SAVE_FILE       = "networkSummary.pq"
+	RELAXATION_RESULT_SAVE_FILE     = "relaxationResult.pq"
+	RELAXATION_SUMMARY_SAVE_FILE    = "relaxationSummary.pq"
+	TARGET_STATE_PROBE_SAVE_FILE    = "targetStateProbe.pq"
+	UNIQUE_STATES_SAVE_FILE         = "uniqueStates.pq"
+	LEARN_STATE_SAVE_FILE           = "learnStateData.pq"
+	LEARN_EPOCH_SAVE_FILE           = "learnEpochData.pq"
+	MATRIX_SNAPSHOT_DIRECTORY       = "matrixSnapshots"
+	SPECTRUM_SAVE_FILE              = "spectrum.pq"
+	SPECTRAL_SUMMARY_SAVE_FILE      = "spectralSummary.pq"
+	TARGET_PROJECTION_SAVE_FILE     = "targetProjection.pq"
+	RELAXATION_HISTORY_SAVE_FILE    = "relaxationHistory.pq"
+	LOG_SAVE_FILE                   = "log.txt"
+
+	// The number of events buffered by the data collector, so relaxation need not wait on writing data
+	DATA_COLLECTOR_BUFFER_SIZE = 1024
+)
+
+// A brief summary of the outcome of a trial, useful for collating many trials (e.g. in a sweep).
+type TrialResult struct {
+	TargetStates       int
+	StableTargetStates int
+	ProbeStates        int
+	StableProbeStates  int
+}
+
+// Run a single trial: generate (or load) target states, learn them, probe the network, and write all data.
+//
+// The trial is entirely described by the given configuration, so many trials can be run concurrently
+// provided each is given a separate data directory. Note the configuration is updated in place if states are
+// loaded from file, so the resolved configuration is recorded correctly.
+//
+// # Arguments
+//
+// config *ExperimentConfig: The (validated) configuration of this trial
+//
+// dataDirectory string: The directory to write all data files to. Must already exist.
+//
+// logger *log.Logger: The logger to use for this trial
+//
+// # Returns
+//
+// (TrialResult, nil) on success, (nil, error) if the trial could not be completed
+func runTrial(config *ExperimentConfig, dataDirectory string, logger *log.Logger) (*TrialResult, error) {
+	logger.Printf("Creating data collector")
+	collector, err := newDataCollector(config, dataDirectory)
+	if err != nil {
+		return nil, err
+	}
+	collector.Start()
+	// Close is called explicitly on success to report errors, this ensures the data is written on failure too
+	defer collector.Close()
+
+	network := newNetworkBuilder(config, collector, logger).Build()
+	stateGenerator := newStateGenerator(config)
+
+	trialResult, err := trainNetwork(config, network, stateGenerator, dataDirectory, logger)
+	if err != nil {
+		return nil, err
+	}
+	// Do not spend time probing if the learning data could not be written
+	if err := collector.Err(); err != nil {
+		return nil, err
+	}
+	if err := probeNetwork(config, network, stateGenerator, dataDirectory, trialResult, logger); err != nil {
+		return nil, err
+	}
+
+	// CLEAN UP & FINISH --------------------------------------------------------------------------
+	logger.SetPrefix("Clean Up: ")
+	if err := writeTrialRecord(config, network, dataDirectory); err != nil {
+		return nil, err
+	}
+	if err := collector.Close(); err != nil {
+		return nil, err
+	}
+	logger.Printf("Data written successfully")
+
+	return trialResult, nil
+}
+
+// Create a DataCollector with a handler for each data file enabled in the configuration.
+//
+// If any handler can not be created the handlers created so far are closed and an error is returned.
+func newDataCollector(config *ExperimentConfig, dataDirectory string) (*datacollector.DataCollector, error) {
+	dataCollectionConfig := config.DataCollection
+	newUniqueRelaxedStateHandler := func(dataFile string, settings datacollector.DataFileSettings) (datacollector.DataHandler, error) {
+		return datacollector.NewUniqueRelaxedStateHandler(dataFile, settings, config.Network.Domain)
+	}
+	handlerConstructors := []struct {
+		enabled    bool
+		dataFile   string
+		newHandler func(string, datacollector.DataFileSettings) (datacollector.DataHandler, error)
+	}{
+		{dataCollectionConfig.RelaxationResult, RELAXATION_RESULT_SAVE_FILE, datacollector.NewRelaxationResultHandler},
+		{dataCollectionConfig.TargetStateProbe, TARGET_STATE_PROBE_SAVE_FILE, datacollector.NewTargetStateProbeHandler},
+		{dataCollectionConfig.UniqueRelaxedStates, UNIQUE_STATES_SAVE_FILE, newUniqueRelaxedStateHandler},
+		{dataCollectionConfig.LearnState, LEARN_STATE_SAVE_FILE, datacollector.NewLearnStateHandler},
+		{dataCollectionConfig.LearnEpoch, LEARN_EPOCH_SAVE_FILE, datacollector.NewLearnEpochHandler},
+		// Only add this collector if we want to collect intensive data. Avoids creating additional files and extra listeners.
+		{dataCollectionConfig.RelaxationHistory, RELAXATION_HISTORY_SAVE_FILE, datacollector.NewRelaxationHistoryData},
+	}
+
+	collector := datacollector.NewBufferedDataCollector(DATA_COLLECTOR_BUFFER_SIZE)
+	for _, handlerConstructor := range handlerConstructors {
+		if !handlerConstructor.enabled {
+			continue
+		}
+		dataFile := dataFileName(handlerConstructor.dataFile, dataCollectionConfig.OutputFormat)
+		handler, err := handlerConstructor.newHandler(path.Join(dataDirectory, dataFile), dataCollectionConfig.DataFileSettings())
+		if err != nil {
+			collector.Close()
+			return nil, fmt.Errorf("data handler creation failed: %w", err)
+		}
+		collector.AddHandler(handler)
+	}
+
+	// Matrix snapshots are written as one binary file per snapshot, so are not affected by the output format
+	if dataCollectionConfig.MatrixSnapshotInterval > 0 {
+		handler, err := datacollector.NewMatrixSnapshotHandler(path.Join(dataDirectory, MATRIX_SNAPSHOT_DIRECTORY))
+		if err != nil {
+			collector.Close()
+			return nil, fmt.Errorf("data handler creation failed: %w", err)
+		}
+		collector.AddHandler(handler)
+	}
+	return collector, nil
+}
+
+// Get the name of a data file written in the given output format, by replacing the extension of the (parquet) save file.
+//
+// For example, relaxationResult.pq is written as relaxationResult.csv in the CSV format.
+func dataFileName(saveFile string, outputFormat datacollector.OutputFormatEnum) string {
+	return strings.TrimSuffix(saveFile, path.Ext(saveFile)) + outputFormat.FileExtension()
+}
+
+// Create a HopfieldNetworkBuilder with all settings taken from the configuration.
+//
+// The builder is returned (rather than the network) so callers may set additional options, such as a saved matrix, before building.
+func newNetworkBuilder(config *ExperimentConfig, collector *datacollector.DataCollector, logger *log.Logger) *hopfieldnetwork.HopfieldNetworkBuilder {
+	return hopfieldnetwork.NewHopfieldNetworkBuilder().
+		SetNetworkDomain(config.Network.Domain).
+		SetNetworkDimension(config.Network.Dimension).
+		SetRandMatrixInit(config.Network.RandomMatrixInit).
+		SetForceSymmetric(config.Network.ForceSymmetric).
+		SetForceZeroDiagonal(config.Network.ForceZeroDiagonal).
+		SetDistanceMeasure(config.Network.DistanceMeasure).
+		SetNetworkLearningMethod(config.Learning.Method).
+		SetNetworkLearningRule(config.Learning.Rule).
+		SetEpochs(config.Learning.Epochs).
+		SetMaximumRelaxationIterations(config.Network.MaximumRelaxationIterations).
+		SetMaximumRelaxationUnstableUnits(config.Network.MaximumRelaxationUnstableUnits).
+		SetLearningRate(config.Learning.Rate).
+		SetLearningNoiseMethod(config.Learning.NoiseMethod).
+		SetLearningNoiseRatio(config.Learning.NoiseScale).
+		SetUnitsUpdatedPerStep(config.Network.UnitsUpdated).
+		SetDataCollector(collector).
+		SetLogger(logger).
+		SetAllowIntensiveDataCollection(config.DataCollection.RelaxationHistory).
+		SetRelaxationHistoryPolicy(config.DataCollection.RelaxationHistoryPolicy).
+		SetLearnEpochEigenvalues(config.DataCollection.LearnEpochEigenvalues).
+		SetMatrixSnapshotInterval(config.DataCollection.MatrixSnapshotInterval).
+		SetSeed(config.Seed)
+}
+
+// Create a StateGenerator for the domain and dimension of the configuration.
+//
+// The state generator is seeded separately from the network so the two random streams are independent.
+func newStateGenerator(config *ExperimentConfig) *states.StateGenerator {
+	stateGeneratorSeed := config.Seed
+	if stateGeneratorSeed != 0 {
+		stateGeneratorSeed += 1
+	}
+	return states.NewStateGeneratorBuilder().
+		SetRandMin(-1).
+		SetRandMax(1).
+		SetGeneratorDomain(config.Network.Domain).
+		SetGeneratorDimension(config.Network.Dimension).
+		SetSeed(stateGeneratorSeed).
+		Build()
+}
+
+// Generate (or load) the target states, learn them, save the learned network, and probe each target state.
+//
+// # Returns
+//
+// (TrialResult with the target state fields set, nil) on success, (nil, error) on errors
+func trainNetwork(config *ExperimentConfig, network *hopfieldnetwork.HopfieldNetwork, stateGenerator *states.StateGenerator, dataDirectory string, logger *log.Logger) (*TrialResult, error) {
+	var err error
+
+	// LEARNING PHASE -----------------------------------------------------------------------------
+	logger.SetPrefix("Network Learning: ")
+	// Either load states from file or generate a random number of states, based on the configuration
+
+	// The target states of this network
+	var targetStates []*mat.VecDense
+
+	if config.States.TargetStatesFile == "" {
+		// If we are not given a file to load, generate a random collection
+		targetStates = stateGenerator.CreateStateCollection(config.States.NumTargetStates)
+	} else {
+		// We have a file to load, do so
+		targetStates, err = loadVectorCollection(config.States.TargetStatesFile)
+		if err != nil {
+			return nil, fmt.Errorf("target states loading failed: %w", err)
+		}
+		// Manually set the number of target states so the resolved configuration is correct
+		config.States.NumTargetStates = len(targetStates)
+	}
+
+	// Actually learn the target states, the network sends the learning data to the collector
+	network.LearnStates(targetStates)
+
+	// Save the weight matrix to the specified path.
+	// Note the errors of file operations include the file path, so the offending file is reported
+	if err := gonumio.SaveMatrix(network.GetMatrix(), path.Join(dataDirectory, LEARNED_MATRIX_BINARY_SAVE_FILE)); err != nil {
+		return nil, fmt.Errorf("matrix saving failed: %w", err)
+	}
+	if err := gonumio.SaveVectorCollection(targetStates, path.Join(dataDirectory, TARGET_STATES_BINARY_SAVE_FILE)); err != nil {
+		return nil, fmt.Errorf("target states saving failed: %w", err)
+	}
+	// Also save numpy compatible copies for analysis in Python
+	if err := npyio.SaveMatrix(network.GetMatrix(), path.Join(dataDirectory, LEARNED_MATRIX_NPY_SAVE_FILE)); err != nil {
+		return nil, fmt.Errorf("matrix npy saving failed: %w", err)
+	}
+	if err := npyio.SaveVectorCollection(targetStates, path.Join(dataDirectory, TARGET_STATES_NPY_SAVE_FILE)); err != nil {
+		return nil, fmt.Errorf("target states npy saving failed: %w", err)
+	}
+
+	if config.DataCollection.Spectrum {
+		logger.Printf("Analyzing spectrum of learned matrix")
+		if err := writeSpectralAnalysis(config, network.GetMatrix(), targetStates, dataDirectory); err != nil {
+			return nil, err
+		}
+	}
+
+	// Analyze specifically the learned states and save those results too
+	trialResult := &TrialResult{
+		TargetStates: len(targetStates),
+	}
+	for _, targetStateData := range network.ProbeTargetStates() {
+		if targetStateData.IsStable {
+			trialResult.StableTargetStates += 1
+		}
+	}
+
+	return trialResult, nil
+}
+
+// Generate (or load) the probe states and relax them. The network sends the results to its data collector.
+//
+// If relaxation results are collected, the convergence of all probe states is also summarized in relaxationSummary.pq.
+// The probe state fields of the trialResult are updated.
+func probeNetwork(config *ExperimentConfig, network *hopfieldnetwork.HopfieldNetwork, stateGenerator *states.StateGenerator, dataDirectory string, trialResult *TrialResult, logger *log.Logger) error {
+	var err error
+
+	// PROBING PHASE ------------------------------------------------------------------------------
+	logger.SetPrefix("Network Probing: ")
+	// Create and relax a set of probe states
+
+	var probeStates []*mat.VecDense
+	if config.States.ProbeStatesFile == "" {
+		probeStates = stateGenerator.CreateStateCollection(config.States.NumProbeStates)
+	} else {
+		probeStates, err = loadVectorCollection(config.States.ProbeStatesFile)
+		if err != nil {
+			return fmt.Errorf("probe states loading failed: %w", err)
+		}
+		config.States.NumProbeStates = len(probeStates)
+	}
+
+	// The network sends the relaxation results (and histories) to the collector
+	relaxationResults := network.ConcurrentRelaxStates(probeStates, config.Probing.Threads)
+
+	trialResult.ProbeStates = len(relaxationResults)
+	for _, result := range relaxationResults {
+		if result.Stable {
+			trialResult.StableProbeStates += 1
+		}
+	}
+
+	if config.DataCollection.RelaxationResult {
+		relaxationSummaryFile := path.Join(dataDirectory, dataFileName(RELAXATION_SUMMARY_SAVE_FILE, config.DataCollection.OutputFormat))
+		if err := datacollector.WriteRelaxationSummary(relaxationSummaryFile, config.DataCollection.DataFileSettings(), summarizeRelaxationResults(relaxationResults)); err != nil {
+			return fmt.Errorf("relaxation summary saving failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Aggregate the convergence of a collection of relaxation results.
+func summarizeRelaxationResults(results []*hopfieldnetwork.RelaxationResult) *datacollector.RelaxationSummaryData {
+	summary := &datacollector.RelaxationSummaryData{
+		ProbeStates: len(results),
+	}
+	if len(results) == 0 {
+		return summary
+	}
+
+	totalSweeps := 0
+	totalUnitFlips := 0
+	totalEnergyDecrease := 0.0
+	for _, result := range results {
+		if result.Stable {
+			summary.StableProbeStates += 1
+		}
+		if result.ExactRecall {
+			summary.ExactRecalls += 1
+		}
+		if result.InverseRecall {
+			summary.InverseRecalls += 1
+		}
+		if result.EnergyIncreased {
+			summary.EnergyIncreasedProbes += 1
+		}
+		if result.Sweeps > summary.MaximumSweeps {
+			summary.MaximumSweeps = result.Sweeps
+		}
+		totalSweeps += result.Sweeps
+		totalUnitFlips += result.UnitFlips
+		totalEnergyDecrease += result.EnergyDecrease
+	}
+	summary.MeanSweeps = float64(totalSweeps) / float64(len(results))
+	summary.MeanUnitFlips = float64(totalUnitFlips) / float64(len(results))
+	summary.MeanEnergyDecrease = totalEnergyDecrease / float64(len(results))
+	return summary
+}
+
+// Write the network summary and the resolved configuration to the data directory, as a record of this trial.
+func writeTrialRecord(config *ExperimentConfig, network *hopfieldnetwork.HopfieldNetwork, dataDirectory string) error {
+	hopfieldNetworkSummary := network.GetNetworkSummary()
+	networkSummaryData := datacollector.HopfieldNetworkSummaryData{
+		NetworkDomain:               config.Network.Domain.String(),
+		NetworkDimension:            hopfieldNetworkSummary.Dimension,
+		LearningRule:                config.Learning.Rule.String(),
+		Epochs:                      hopfieldNetworkSummary.Epochs,
+		MaximumRelaxationIterations: hopfieldNetworkSummary.MaximumRelaxationIterations,
+		LearningRate:                config.Learning.Rate,
+		LearningNoiseMethod:         config.Learning.NoiseMethod.String(),
+		LearningNoiseScale:          hopfieldNetworkSummary.LearningNoiseScale,
+		UnitsUpdated:                hopfieldNetworkSummary.UnitsUpdatedPerStep,
+		ForceSymmetricWeightMatrix:  hopfieldNetworkSummary.ForceSymmetric,
+		ForceZeroBias:               hopfieldNetworkSummary.ForceZeroDiagonal,
+		DistanceMeasure:             config.Network.DistanceMeasure.String(),
+		Threads:                     config.Probing.Threads,
+		TargetStates:                config.States.NumTargetStates,
+		ProbeStates:                 config.States.NumProbeStates,
+		OutputFormat:                config.DataCollection.OutputFormat.String(),
+		ParquetCompression:          config.DataCollection.ParquetWriter.Compression.String(),
+		ParquetRowGroupSizeMB:       config.DataCollection.ParquetWriter.RowGroupSizeMB,
+		ParquetPageSizeKB:           config.DataCollection.ParquetWriter.PageSizeKB,
+		ParquetParallelism:          config.DataCollection.ParquetWriter.Parallelism,
+	}
+	networkSummaryFile := path.Join(dataDirectory, dataFileName(NETWORK_SUMMARY_SAVE_FILE, config.DataCollection.OutputFormat))
+	if err := datacollector.WriteHopfieldNetworkSummary(networkSummaryFile, config.DataCollection.DataFileSettings(), &networkSummaryData); err != nil {
+		return fmt.Errorf("network summary saving failed: %w", err)
+	}
+
+	// Record the resolved configuration of this trial for provenance
+	if err := config.WriteFile(path.Join(dataDirectory, EXPERIMENT_CONFIG_SAVE_FILE)); err != nil {
+		return fmt.Errorf("configuration saving failed: %w", err)
+	}
+	return nil
+}
+
+// Compute the spectrum of the learned weight matrix and the projection of each target state onto its eigenvectors,
+// writing the spectrum, spectral summary and target projection data files.
+func writeSpectralAnalysis(config *ExperimentConfig, matrix *mat.Dense, targetStates []*mat.VecDense, dataDirectory string) error {
+	spectrum, err := spectralanalysis.ComputeSpectrum(matrix)
+	if err != nil {
+		return fmt.Errorf("spectral analysis failed: %w", err)
+	}
+
+	spectrumData := make([]*datacollector.SpectrumData, len(spectrum.Eigenvalues))
+	for eigenvalueIndex, eigenvalue := range spectrum.Eigenvalues {
+		spectrumData[eigenvalueIndex] = &datacollector.SpectrumData{
+			EigenvalueIndex:     eigenvalueIndex,
+			EigenvalueReal:      real(eigenvalue),
+			EigenvalueImaginary: imag(eigenvalue),
+			SingularValue:       spectrum.SingularValues[eigenvalueIndex],
+		}
+	}
+	summaryData := &datacollector.SpectralSummaryData{
+		Dimension:      len(spectrum.Eigenvalues),
+		Symmetric:      spectrum.Symmetric,
+		EffectiveRank:  spectrum.EffectiveRank,
+		NumericalRank:  spectrum.NumericalRank,
+		SpectralRadius: spectrum.SpectralRadius(),
+	}
+	projectionData := make([]*datacollector.TargetProjectionData, len(targetStates))
+	for targetIndex, targetState := range targetStates {
+		projection := spectrum.ProjectTargetState(matrix, targetState)
+		projectionData[targetIndex] = &datacollector.TargetProjectionData{
+			TargetIndex:            targetIndex,
+			Alignments:             projection.Alignments,
+			MaximumAlignment:       projection.MaximumAlignment,
+			AlignedEigenvalueIndex: projection.AlignedEigenvalueIndex,
+			RayleighQuotient:       projection.RayleighQuotient,
+		}
+	}
+
+	settings := config.DataCollection.DataFileSettings()
+	outputFormat := config.DataCollection.OutputFormat
+	if err := datacollector.WriteSpectrum(path.Join(dataDirectory, dataFileName(SPECTRUM_SAVE_FILE, outputFormat)), settings, spectrumData); err != nil {
+		return fmt.Errorf("spectrum saving failed: %w", err)
+	}
+	if err := datacollector.WriteSpectralSummary(path.Join(dataDirectory, dataFileName(SPECTRAL_SUMMARY_SAVE_FILE, outputFormat)), settings, summaryData); err != nil {
+		return fmt.Errorf("spectral summary saving failed: %w", err)
+	}
+	if err := datacollector.WriteTargetProjections(path.Join(dataDirectory, dataFileName(TARGET_PROJECTION_SAVE_FILE, outputFormat)), settings, projectionData); err != nil {
+		return fmt.Errorf("target projection saving failed: %w", err)
+	}
+	return nil
+}
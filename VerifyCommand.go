@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path"
+
+	"hmcalister/hopfield/hopfieldnetwork"
+	"hmcalister/hopfield/hopfieldnetwork/datacollector"
+)
+
+const (
+	ENERGY_VERIFICATION_SAVE_FILE        = "energyVerification.pq"
+	ENERGY_VERIFICATION_PROBES_SAVE_FILE = "energyVerificationProbes.pq"
+)
+
+// Verify that relaxation never increases the energy of a state, for a saved network, from the given command line arguments.
+//
+// Asynchronous updates are only guaranteed to descend the energy -1/2 x^T W x (making the energy a Lyapunov function of the dynamics)
+// for a symmetric weight matrix with a non-negative diagonal. Random probe states are relaxed with energy descent verification
+// enabled (see HopfieldNetworkBuilder.SetVerifyEnergyDescent), so the energy is checked after every unit update that changes a unit.
+// In the binary domain this energy is taken over the binary state, which differs from the (bipolar mapped) state energy recorded
+// in the other data files, as only the former is descended by the binary update.
+//
+// The violations of every probe are written to energyVerificationProbes.pq, and the violations over all probes, along with the
+// asymmetry and diagonal of the weight matrix, are written to energyVerification.pq. The verdict is printed, and an error is
+// returned (once all files are written) if any update increased the energy.
+func runVerifyCommand(args []string) error {
+	verifyFlags := flag.NewFlagSet("verify", flag.ExitOnError)
+	networkDirectory := verifyFlags.String("networkDir", "", "The run directory of the saved network, as written by the train or run command. Required.")
+	seed := verifyFlags.Uint64("seed", 0, "The seed for the random generators of the network and probes. Defaults to the seed of the saved network.")
+	numProbeStates := verifyFlags.Int("numProbeStates", 1000, "The number of random probe states to relax.")
+	numThreads := verifyFlags.Int("threads", 1, "The number of threads to use for relaxation. Defaults to the value of the saved network.")
+	dataFileFlags := addDataFileFlags(verifyFlags, defaultExperimentConfig().DataCollection, "\nDefaults to the value of the saved network.")
+	outputFlags := addOutputFlags(verifyFlags, "data/hopfieldVerify")
+	verifyFlags.Parse(args)
+
+	if *networkDirectory == "" {
+		return errors.New("networkDir must be given")
+	}
+	if *numProbeStates <= 0 {
+		return fmt.Errorf("numProbeStates must be a positive integer, got %d", *numProbeStates)
+	}
+
+	config, matrix, targetStates, targetStatesGenerated, err := loadSavedNetwork(*networkDirectory)
+	if err != nil {
+		return err
+	}
+	// The probes of this command are not written as relaxation results, so no data is collected by the network
+	config.DataCollection.RelaxationHistory = false
+	config.States.NumProbeStates = *numProbeStates
+	config.States.ProbeStatesFile = ""
+	verifyFlags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "seed":
+			config.Seed = *seed
+		case "threads":
+			config.Probing.Threads = *numThreads
+		}
+	})
+	dataFileFlags.applyTo(&config.DataCollection, true)
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	defer startProfiling(*outputFlags.enableProfiling)()
+
+	runDirectory, err := outputFlags.createRunDirectory()
+	if err != nil {
+		return err
+	}
+	logger, err := outputFlags.newLogger(runDirectory)
+	if err != nil {
+		return fmt.Errorf("could not open log file: %w", err)
+	}
+	logger.Printf("Writing to run directory %#v\n", runDirectory)
+
+	collector := datacollector.NewBufferedDataCollector(DATA_COLLECTOR_BUFFER_SIZE)
+	collector.Start()
+	defer collector.Close()
+
+	network := newNetworkBuilder(config, collector, logger).
+		SetMatrix(matrix).
+		SetTargetStates(targetStates).
+		SetVerifyEnergyDescent(true).
+		Build()
+
+	// MATRIX PROPERTIES --------------------------------------------------------------------------
+	logger.SetPrefix("Energy Verification: ")
+	statistics := hopfieldnetwork.ComputeMatrixStatistics(matrix, 0)
+	minimumDiagonal := math.Inf(1)
+	for i := 0; i < config.Network.Dimension; i++ {
+		minimumDiagonal = math.Min(minimumDiagonal, matrix.At(i, i))
+	}
+	logger.Printf("Weight matrix has relative asymmetry %.6g, maximum diagonal magnitude %.6g and minimum diagonal %.6g\n",
+		statistics.RelativeAsymmetry, statistics.MaximumDiagonalMagnitude, minimumDiagonal)
+
+	// RELAX PROBES -------------------------------------------------------------------------------
+	// Skip over the randomly generated target states, so the probe states match those of the probe command
+	stateGenerator := newStateGenerator(config)
+	if targetStatesGenerated {
+		stateGenerator.CreateStateCollection(config.States.NumTargetStates)
+	}
+	probeStates := stateGenerator.CreateStateCollection(config.States.NumProbeStates)
+	results := network.ConcurrentRelaxStates(probeStates, config.Probing.Threads)
+
+	verificationData := &datacollector.EnergyVerificationData{
+		NetworkDimension:         config.Network.Dimension,
+		RelativeAsymmetry:        statistics.RelativeAsymmetry,
+		MaximumDiagonalMagnitude: statistics.MaximumDiagonalMagnitude,
+		MinimumDiagonal:          minimumDiagonal,
+		ProbeStates:              len(results),
+	}
+	probeData := make([]*datacollector.EnergyVerificationProbeData, len(results))
+	for probeIndex, result := range results {
+		probeData[probeIndex] = &datacollector.EnergyVerificationProbeData{
+			ProbeIndex:            probeIndex,
+			Stable:                result.Stable,
+			Sweeps:                result.Sweeps,
+			CheckedUpdates:        result.CheckedUpdates,
+			EnergyViolations:      result.EnergyViolations,
+			MaximumEnergyIncrease: result.MaximumEnergyIncrease,
+		}
+		verificationData.CheckedUpdates += int64(result.CheckedUpdates)
+		verificationData.EnergyViolations += int64(result.EnergyViolations)
+		if result.EnergyViolations > 0 {
+			verificationData.ViolatingProbes += 1
+		}
+		verificationData.MaximumEnergyIncrease = math.Max(verificationData.MaximumEnergyIncrease, result.MaximumEnergyIncrease)
+	}
+	verificationData.EnergyMonotonic = verificationData.EnergyViolations == 0
+
+	var verdict string
+	if verificationData.EnergyMonotonic {
+		verdict = fmt.Sprintf("PASS: Energy never increased over %v checked updates of %v probes",
+			verificationData.CheckedUpdates, verificationData.ProbeStates)
+	} else {
+		verdict = fmt.Sprintf("FAIL: Energy increased in %v of %v checked updates, in %v of %v probes (largest increase %.6g)",
+			verificationData.EnergyViolations, verificationData.CheckedUpdates, verificationData.ViolatingProbes, verificationData.ProbeStates,
+			verificationData.MaximumEnergyIncrease)
+	}
+	logger.Println(verdict)
+	fmt.Fprintf(os.Stdout, "Energy verification of %v\n\t%v\n", *networkDirectory, verdict)
+
+	// CLEAN UP & FINISH --------------------------------------------------------------------------
+	logger.SetPrefix("Clean Up: ")
+	outputFormat := config.DataCollection.OutputFormat
+	if err := datacollector.WriteEnergyVerification(path.Join(runDirectory, dataFileName(ENERGY_VERIFICATION_SAVE_FILE, outputFormat)), config.DataCollection.DataFileSettings(), verificationData); err != nil {
+		return fmt.Errorf("energy verification saving failed: %w", err)
+	}
+	if err := datacollector.WriteEnergyVerificationProbes(path.Join(runDirectory, dataFileName(ENERGY_VERIFICATION_PROBES_SAVE_FILE, outputFormat)), config.DataCollection.DataFileSettings(), probeData); err != nil {
+		return fmt.Errorf("energy verification probes saving failed: %w", err)
+	}
+	if err := config.WriteFile(path.Join(runDirectory, EXPERIMENT_CONFIG_SAVE_FILE)); err != nil {
+		return fmt.Errorf("configuration saving failed: %w", err)
+	}
+	if err := collector.Close(); err != nil {
+		return err
+	}
+
+	manifestConfiguration := struct {
+		Experiment       *ExperimentConfig `json:"experiment"`
+		NetworkDirectory string            `json:"networkDirectory"`
+	}{config, *networkDirectory}
+	if err := writeManifest(runDirectory, "verify", args, manifestConfiguration); err != nil {
+		return fmt.Errorf("could not write manifest: %w", err)
+	}
+
+	logger.Println("DONE")
+	if !verificationData.EnergyMonotonic {
+		return fmt.Errorf("energy increased in %v of %v checked updates", verificationData.EnergyViolations, verificationData.CheckedUpdates)
+	}
+	return nil
+}
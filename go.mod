@@ -10,6 +10,7 @@ require (
 	github.com/schollz/progressbar/v3 v3.13.1
 	github.com/xitongsys/parquet-go-source v0.0.0-20221025031416-9877e685ef65
 	golang.org/x/exp v0.0.0-20230315142452-642cacee5cc0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,170 @@
+package hopfieldnetwork
+
+import (
+	"hmcalister/hopfield/hopfieldnetwork/distancemeasure"
+	"hmcalister/hopfield/hopfieldnetwork/domain"
+	"hmcalister/hopfield/hopfieldutils"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// The largest number of target states a state is tested as a mixture of.
+// Mixtures of many target states have small overlaps with each, and are in practice indistinguishable from spin-glass states.
+const MAXIMUM_MIXTURE_COMPONENTS = 7
+
+type AttractorClassEnum int
+
+const (
+	// The state is equal to a target state
+	TargetAttractor AttractorClassEnum = iota
+
+	// The state is equal to the inverse of a target state
+	InverseTargetAttractor AttractorClassEnum = iota
+
+	// The state is the sign of an equally weighted sum of an odd number of (possibly inverted) target states
+	SymmetricMixtureAttractor AttractorClassEnum = iota
+
+	// The state is the sign of a sum of (possibly inverted) target states weighted by the overlap of the state with each,
+	// but not of the equally weighted sum
+	AsymmetricMixtureAttractor AttractorClassEnum = iota
+
+	// The state is not related to any small set of target states
+	SpinGlassAttractor AttractorClassEnum = iota
+)
+
+// All valid AttractorClassEnum values, used when parsing attractor classes from strings.
+var attractorClassEnumValues = []AttractorClassEnum{
+	TargetAttractor,
+	InverseTargetAttractor,
+	SymmetricMixtureAttractor,
+	AsymmetricMixtureAttractor,
+	SpinGlassAttractor,
+}
+
+// Parse an AttractorClassEnum from either its name (e.g. "SpinGlassAttractor", case insensitive) or its integer value.
+func ParseAttractorClassEnum(name string) (AttractorClassEnum, error) {
+	return hopfieldutils.ParseEnum(name, attractorClassEnumValues)
+}
+
+// The classification of a (relaxed) state by its relation to the target states of a network.
+//
+// MixtureTargets are the indices of the target states composing the state, and MixtureSigns the sign (+1, or -1 if inverted)
+// each target state is taken with. A target state (or inverse) is a mixture of a single target state, and a spin-glass state is
+// a mixture of none.
+type AttractorClassification struct {
+	Class          AttractorClassEnum
+	MixtureTargets []int
+	MixtureSigns   []int
+}
+
+// Classify a state by its relation to a collection of target states.
+//
+// States are compared in the bipolar domain, so binary states are mapped to bipolar states first. The state is first compared
+// to each target state (and inverse) directly. Otherwise, the target states are ordered by the magnitude of their overlap
+// with the state, and for k = 2, 3, ..., MAXIMUM_MIXTURE_COMPONENTS the state is compared to mixtures of the k target states with the
+// largest overlaps, each taken with the sign of its overlap. A state equal to the sign of the equally weighted sum
+// of an odd number of these target states is a symmetric mixture, while a state equal to the sign of the sum weighted by the overlaps
+// (the mean field description of a mixture state) is an asymmetric mixture. Units where a sum is exactly zero match either value.
+// The smallest k with a match is used. A state that is not a mixture of any k is a spin-glass state.
+//
+// # Arguments
+//
+// state *mat.VecDense: The state to classify
+//
+// targetStates []*mat.VecDense: The target states of the network
+//
+// networkDomain domain.DomainEnum: The domain of the state and target states
+//
+// # Returns
+//
+// The classification of the state
+func ClassifyAttractor(state *mat.VecDense, targetStates []*mat.VecDense, networkDomain domain.DomainEnum) *AttractorClassification {
+	bipolarState := bipolarStateOf(state, networkDomain)
+	bipolarTargetStates := make([]*mat.VecDense, len(targetStates))
+	overlaps := make([]float64, len(targetStates))
+	for targetIndex, targetState := range targetStates {
+		bipolarTargetStates[targetIndex] = bipolarStateOf(targetState, networkDomain)
+		overlaps[targetIndex] = distancemeasure.Overlap(targetState, state, networkDomain)
+	}
+
+	for targetIndex, overlap := range overlaps {
+		if overlap == 1.0 {
+			return &AttractorClassification{Class: TargetAttractor, MixtureTargets: []int{targetIndex}, MixtureSigns: []int{1}}
+		}
+		if overlap == -1.0 {
+			return &AttractorClassification{Class: InverseTargetAttractor, MixtureTargets: []int{targetIndex}, MixtureSigns: []int{-1}}
+		}
+	}
+
+	// Order the target states by the magnitude of their overlap with the state, largest first
+	orderedTargets := make([]int, len(targetStates))
+	for targetIndex := range orderedTargets {
+		orderedTargets[targetIndex] = targetIndex
+	}
+	sort.SliceStable(orderedTargets, func(i, j int) bool {
+		return math.Abs(overlaps[orderedTargets[i]]) > math.Abs(overlaps[orderedTargets[j]])
+	})
+
+	symmetricSum := mat.NewVecDense(bipolarState.Len(), nil)
+	weightedSum := mat.NewVecDense(bipolarState.Len(), nil)
+	for numComponents := 1; numComponents <= len(orderedTargets) && numComponents <= MAXIMUM_MIXTURE_COMPONENTS; numComponents++ {
+		targetIndex := orderedTargets[numComponents-1]
+		overlap := overlaps[targetIndex]
+		if overlap == 0 {
+			// This and all later target states are orthogonal to the state, so can not be components of a mixture
+			break
+		}
+		symmetricSum.AddScaledVec(symmetricSum, math.Copysign(1.0, overlap), bipolarTargetStates[targetIndex])
+		weightedSum.AddScaledVec(weightedSum, overlap, bipolarTargetStates[targetIndex])
+
+		// A single target state has already been compared directly
+		if numComponents == 1 {
+			continue
+		}
+		class := SpinGlassAttractor
+		if numComponents%2 == 1 && isSignOf(bipolarState, symmetricSum) {
+			class = SymmetricMixtureAttractor
+		} else if isSignOf(bipolarState, weightedSum) {
+			class = AsymmetricMixtureAttractor
+		}
+		if class == SpinGlassAttractor {
+			continue
+		}
+
+		classification := &AttractorClassification{
+			Class:          class,
+			MixtureTargets: make([]int, numComponents),
+			MixtureSigns:   make([]int, numComponents),
+		}
+		for componentIndex, componentTargetIndex := range orderedTargets[:numComponents] {
+			classification.MixtureTargets[componentIndex] = componentTargetIndex
+			classification.MixtureSigns[componentIndex] = int(math.Copysign(1.0, overlaps[componentTargetIndex]))
+		}
+		return classification
+	}
+
+	return &AttractorClassification{Class: SpinGlassAttractor, MixtureTargets: []int{}, MixtureSigns: []int{}}
+}
+
+// Get a bipolar copy of a state, mapping binary states onto bipolar states.
+func bipolarStateOf(state *mat.VecDense, networkDomain domain.DomainEnum) *mat.VecDense {
+	bipolarState := mat.VecDenseCopyOf(state)
+	if networkDomain == domain.BinaryDomain {
+		for i := 0; i < bipolarState.Len(); i++ {
+			bipolarState.SetVec(i, 2*bipolarState.AtVec(i)-1)
+		}
+	}
+	return bipolarState
+}
+
+// Determine if a bipolar state is the sign of a vector, where units of the vector that are exactly zero match either value.
+func isSignOf(bipolarState *mat.VecDense, vector *mat.VecDense) bool {
+	for i := 0; i < bipolarState.Len(); i++ {
+		if bipolarState.AtVec(i)*vector.AtVec(i) < 0 {
+			return false
+		}
+	}
+	return true
+}
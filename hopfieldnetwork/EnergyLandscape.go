@@ -0,0 +1,215 @@
+package hopfieldnetwork
+
+import (
+	"fmt"
+	"hmcalister/hopfield/hopfieldnetwork/domain"
+	"sort"
+	"sync"
+
+	"github.com/schollz/progressbar/v3"
+	"gonum.org/v1/gonum/mat"
+)
+
+// The largest network dimension the energy landscape can be enumerated for.
+// The landscape holds the energy (and descent) of all 2^dimension states, so memory grows quickly with dimension.
+const MAXIMUM_LANDSCAPE_DIMENSION = 24
+
+// The number of states enumerated by a goroutine before taking more work.
+const LANDSCAPE_CHUNK_SIZE = 4096
+
+// The energy difference below which two states are taken to have equal energy, to avoid floating point noise creating minima.
+const LANDSCAPE_ENERGY_TOLERANCE = 1e-9
+
+// A local minimum of an energy landscape.
+//
+// StateIndex is the index of the minimum in the enumeration of all states (see EnergyLandscape.State).
+// BasinSize is the number of states that descend to this minimum, including the minimum itself.
+type LocalMinimum struct {
+	StateIndex int
+	State      *mat.VecDense
+	Energy     float64
+	BasinSize  int
+}
+
+// The exhaustively enumerated energy landscape of a weight matrix under single unit flips.
+//
+// States are enumerated by index, where unit i of a state is set if bit i of the index is set.
+// A local minimum is a state where no single unit flip lowers the energy. Every state descends deterministically
+// to a local minimum by repeatedly applying the unit flip that lowers the energy the most (ties broken by lowest unit index).
+type EnergyLandscape struct {
+	dimension     int
+	networkDomain domain.DomainEnum
+	energies      []float64
+	// The state index each state descends to in one step, or the state itself for a local minimum
+	descent []int32
+	Minima  []*LocalMinimum
+}
+
+// Enumerate the energy landscape of a weight matrix, computing the energy of every state with the DomainManager of the domain.
+//
+// The energies, descents and basins are each computed by numThreads goroutines in parallel.
+//
+// # Arguments
+//
+// matrix *mat.Dense: The (square) weight matrix
+//
+// networkDomain domain.DomainEnum: The domain of the states
+//
+// numThreads int: The number of goroutines to enumerate states with
+//
+// # Returns
+//
+// The energy landscape, or an error if the dimension of the matrix is larger than MAXIMUM_LANDSCAPE_DIMENSION
+func EnumerateEnergyLandscape(matrix *mat.Dense, networkDomain domain.DomainEnum, numThreads int) (*EnergyLandscape, error) {
+	dimension, _ := matrix.Dims()
+	if dimension > MAXIMUM_LANDSCAPE_DIMENSION {
+		return nil, fmt.Errorf("energy landscape can only be enumerated up to dimension %d, got %d", MAXIMUM_LANDSCAPE_DIMENSION, dimension)
+	}
+	numStates := 1 << dimension
+	landscape := &EnergyLandscape{
+		dimension:     dimension,
+		networkDomain: networkDomain,
+		energies:      make([]float64, numStates),
+		descent:       make([]int32, numStates),
+	}
+	domainManager := domain.GetDomainManager(networkDomain)
+
+	// Energies of all states
+	landscape.enumerateStates(numThreads, "ENUMERATING ENERGIES", func() func(int) {
+		state := mat.NewVecDense(dimension, nil)
+		return func(stateIndex int) {
+			landscape.setState(state, stateIndex)
+			landscape.energies[stateIndex] = domainManager.StateEnergy(matrix, state)
+		}
+	})
+
+	// Steepest single flip descent of all states
+	landscape.enumerateStates(numThreads, "FINDING DESCENTS", func() func(int) {
+		return func(stateIndex int) {
+			nextStateIndex := stateIndex
+			for unitIndex := 0; unitIndex < dimension; unitIndex++ {
+				neighborIndex := stateIndex ^ (1 << unitIndex)
+				if landscape.energies[neighborIndex] < landscape.energies[nextStateIndex]-LANDSCAPE_ENERGY_TOLERANCE {
+					nextStateIndex = neighborIndex
+				}
+			}
+			landscape.descent[stateIndex] = int32(nextStateIndex)
+		}
+	})
+
+	// Basin sizes, counted separately by each goroutine and merged after
+	var threadBasinSizesMutex sync.Mutex
+	threadBasinSizes := []map[int]int{}
+	landscape.enumerateStates(numThreads, "MEASURING BASINS", func() func(int) {
+		basinSizes := map[int]int{}
+		threadBasinSizesMutex.Lock()
+		threadBasinSizes = append(threadBasinSizes, basinSizes)
+		threadBasinSizesMutex.Unlock()
+		return func(stateIndex int) {
+			basinSizes[landscape.DescendToMinimum(stateIndex)] += 1
+		}
+	})
+	basinSizes := map[int]int{}
+	for _, threadBasinSize := range threadBasinSizes {
+		for minimumIndex, basinSize := range threadBasinSize {
+			basinSizes[minimumIndex] += basinSize
+		}
+	}
+
+	landscape.Minima = make([]*LocalMinimum, 0, len(basinSizes))
+	for minimumIndex, basinSize := range basinSizes {
+		landscape.Minima = append(landscape.Minima, &LocalMinimum{
+			StateIndex: minimumIndex,
+			State:      landscape.State(minimumIndex),
+			Energy:     landscape.energies[minimumIndex],
+			BasinSize:  basinSize,
+		})
+	}
+	sort.Slice(landscape.Minima, func(i, j int) bool {
+		return landscape.Minima[i].StateIndex < landscape.Minima[j].StateIndex
+	})
+	return landscape, nil
+}
+
+// Apply a function to every state index, split into chunks across numThreads goroutines.
+//
+// newStateFunction is called once per goroutine, so each goroutine may allocate its own memory (e.g. a state vector) to reuse.
+func (landscape *EnergyLandscape) enumerateStates(numThreads int, description string, newStateFunction func() func(int)) {
+	numStates := len(landscape.energies)
+	bar := progressbar.Default(int64(numStates), description)
+	chunkChannel := make(chan int, numThreads)
+	var workerGroup sync.WaitGroup
+	for workerIndex := 0; workerIndex < numThreads; workerIndex++ {
+		workerGroup.Add(1)
+		go func() {
+			defer workerGroup.Done()
+			stateFunction := newStateFunction()
+			for chunkStart := range chunkChannel {
+				chunkEnd := chunkStart + LANDSCAPE_CHUNK_SIZE
+				if chunkEnd > numStates {
+					chunkEnd = numStates
+				}
+				for stateIndex := chunkStart; stateIndex < chunkEnd; stateIndex++ {
+					stateFunction(stateIndex)
+				}
+				bar.Add(chunkEnd - chunkStart)
+			}
+		}()
+	}
+	for chunkStart := 0; chunkStart < numStates; chunkStart += LANDSCAPE_CHUNK_SIZE {
+		chunkChannel <- chunkStart
+	}
+	close(chunkChannel)
+	workerGroup.Wait()
+}
+
+// Set the units of a state vector to the state with the given index.
+func (landscape *EnergyLandscape) setState(state *mat.VecDense, stateIndex int) {
+	unsetValue := -1.0
+	if landscape.networkDomain == domain.BinaryDomain {
+		unsetValue = 0.0
+	}
+	for unitIndex := 0; unitIndex < landscape.dimension; unitIndex++ {
+		if stateIndex&(1<<unitIndex) != 0 {
+			state.SetVec(unitIndex, 1.0)
+		} else {
+			state.SetVec(unitIndex, unsetValue)
+		}
+	}
+}
+
+// Get the state with the given index.
+func (landscape *EnergyLandscape) State(stateIndex int) *mat.VecDense {
+	state := mat.NewVecDense(landscape.dimension, nil)
+	landscape.setState(state, stateIndex)
+	return state
+}
+
+// Get the index of a state, the inverse of State. Units are taken as set if they are positive.
+func (landscape *EnergyLandscape) StateIndex(state *mat.VecDense) int {
+	stateIndex := 0
+	for unitIndex := 0; unitIndex < landscape.dimension; unitIndex++ {
+		if state.AtVec(unitIndex) > 0 {
+			stateIndex |= 1 << unitIndex
+		}
+	}
+	return stateIndex
+}
+
+// Get the energy of the state with the given index.
+func (landscape *EnergyLandscape) Energy(stateIndex int) float64 {
+	return landscape.energies[stateIndex]
+}
+
+// Determine if the state with the given index is a local minimum.
+func (landscape *EnergyLandscape) IsMinimum(stateIndex int) bool {
+	return int(landscape.descent[stateIndex]) == stateIndex
+}
+
+// Follow the descent of the state with the given index, returning the index of the local minimum it reaches.
+func (landscape *EnergyLandscape) DescendToMinimum(stateIndex int) int {
+	for !landscape.IsMinimum(stateIndex) {
+		stateIndex = int(landscape.descent[stateIndex])
+	}
+	return stateIndex
+}
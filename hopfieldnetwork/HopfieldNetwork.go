@@ -9,6 +9,7 @@ import (
 	"hmcalister/hopfield/hopfieldnetwork/noiseapplication"
 	"hmcalister/hopfield/hopfieldutils"
 	"log"
+	"math"
 
 	"github.com/schollz/progressbar/v3"
 	"golang.org/x/exp/rand"
@@ -44,6 +45,12 @@ type HopfieldNetwork struct {
 	dataCollector                  *datacollector.DataCollector
 	logger                         *log.Logger
 	allowIntensiveDataCollection   bool
+	relaxationHistoryPolicy        RelaxationHistoryPolicy
+	learnEpochEigenvalues          int
+	matrixSnapshotInterval         int
+	verifyEnergyDescent            bool
+	// The number of epochs of learning applied so far, over all calls to LearnStates
+	learnedEpochs int
 }
 
 // ------------------------------------------------------------------------------------------------
@@ -172,6 +179,16 @@ func (network *HopfieldNetwork) StateEnergy(state *mat.VecDense) float64 {
 	return network.domainManager.StateEnergy(network.matrix, state)
 }
 
+// Get the energy of a state that asynchronous updates descend, E = -1/2 x^T W x, used to verify energy descent.
+//
+// For a symmetric weight matrix with a non-negative diagonal no unit update increases this energy, in either domain.
+// In the bipolar domain this is equal to StateEnergy. In the binary domain StateEnergy maps the state to bipolar, giving a
+// different quadratic form (with a linear term) that is not descended by the binary update, so this energy is taken
+// over the binary state itself.
+func (network *HopfieldNetwork) lyapunovEnergy(state *mat.VecDense) float64 {
+	return -0.5 * mat.Inner(state, network.matrix, state)
+}
+
 // Get the energy of a given unit (indexed by i) in the state with respect to the network matrix.
 //
 // # Arguments
@@ -242,6 +259,28 @@ func (network *HopfieldNetwork) AllStatesAreStable(states []*mat.VecDense) bool
 	return true
 }
 
+// Probe each learned (target) state of the network, checking the stability and energy profile of each.
+//
+// A TargetStateProbe event is sent to the data collector for each target state.
+//
+// # Returns
+//
+// A slice of TargetStateProbeData, one for each target state in the order the states were learned
+func (network *HopfieldNetwork) ProbeTargetStates() []*datacollector.TargetStateProbeData {
+	targetStateProbeData := make([]*datacollector.TargetStateProbeData, len(network.targetStates))
+	for stateIndex, state := range network.targetStates {
+		network.logger.Printf("Analyzing Target State %v\n", stateIndex)
+		targetStateProbeData[stateIndex] = &datacollector.TargetStateProbeData{
+			TargetStateIndex: stateIndex,
+			IsStable:         network.StateIsStable(state),
+			State:            state.RawVector().Data,
+			EnergyProfile:    network.AllUnitEnergies(state),
+		}
+		network.dataCollector.Emit(targetStateProbeData[stateIndex])
+	}
+	return targetStateProbeData
+}
+
 // ------------------------------------------------------------------------------------------------
 // LEARNING METHODS
 // ------------------------------------------------------------------------------------------------
@@ -258,18 +297,110 @@ func (network *HopfieldNetwork) LearnStates(states []*mat.VecDense) []*datacolle
 	network.targetStates = append(network.targetStates, states...)
 	learnStateData := network.learningMethod(network, states)
 	network.matrix.Scale(1/network.matrix.Norm(2), network.matrix)
+	for _, data := range learnStateData {
+		network.dataCollector.Emit(data)
+	}
 	return learnStateData
 }
 
+// Send the LearnEpoch (and, every matrixSnapshotInterval epochs, MatrixSnapshot) events describing the weight matrix
+// after an epoch of learning. Learning methods must call this method once after each epoch.
+//
+// The matrix statistics and snapshots are only computed if the data collector is subscribed to those events.
+func (network *HopfieldNetwork) emitLearnEpoch() {
+	epoch := network.learnedEpochs
+	network.learnedEpochs += 1
+
+	if network.dataCollector.IsSubscribed(datacollector.DataCollectionEvent_LearnEpoch) {
+		statistics := ComputeMatrixStatistics(network.matrix, network.learnEpochEigenvalues)
+		network.dataCollector.Emit(&datacollector.LearnEpochData{
+			Epoch:                    epoch,
+			FrobeniusNorm:            statistics.FrobeniusNorm,
+			MaximumWeight:            statistics.MaximumWeight,
+			MinimumWeight:            statistics.MinimumWeight,
+			RelativeAsymmetry:        statistics.RelativeAsymmetry,
+			MaximumDiagonalMagnitude: statistics.MaximumDiagonalMagnitude,
+			TopEigenvalues:           statistics.TopEigenvalues,
+		})
+	}
+
+	if network.matrixSnapshotInterval > 0 && epoch%network.matrixSnapshotInterval == 0 &&
+		network.dataCollector.IsSubscribed(datacollector.DataCollectionEvent_MatrixSnapshot) {
+		network.dataCollector.Emit(&datacollector.MatrixSnapshotData{
+			Epoch:  epoch,
+			Matrix: mat.DenseCopyOf(network.matrix),
+		})
+	}
+}
+
 // ------------------------------------------------------------------------------------------------
 // STATE UPDATE AND RELAXATION METHODS
 // ------------------------------------------------------------------------------------------------
 
+// The energy difference a sweep must increase the state energy by to be counted as an increase, to avoid floating point noise.
+const RELAXATION_ENERGY_TOLERANCE = 1e-9
+
+// The result of relaxing a single state.
+//
+// NumSteps is the number of states visited during relaxation, including the initial state.
+// FinalState is a copy of the state once relaxation finished, and FinalEnergyProfile the unit energies of that state.
+// FinalOverlaps are the overlaps of the final state with each target state (see distancemeasure.Overlap).
+//
+// NearestTargetIndex is the index of the target state with the smallest distance to the final state, under the distance measure of the
+// network, and MinimumDistance that distance (both -1 if the network has no target states). ExactRecall is true if the final state is
+// the nearest target state or its inverse, and InverseRecall if it is the inverse. Note an inverse is only nearest under a distance
+// measure with inversion (such as the default).
+//
+// If the relaxed state was generated from a target state (see ConcurrentRelaxStatesFromTargets), OriginTargetIndex is the index of
+// that target state, InitialFlippedUnits the number of units of the relaxed state that differed from it, and FlippedUnits the number of
+// units of the final state that differ from it. Otherwise all three are -1.
+//
+// Sweeps is the number of sweeps (updates of every unit) made, and UnitFlips the number of unit updates that changed the value of a unit.
+// SweepEnergies holds the energy of the state before relaxation and after each sweep, and EnergyDecrease is the
+// difference of the first and last of these. EnergyIncreased is true if any sweep increased the energy, which can only
+// happen for asymmetric weight matrices (or matrices with a negative diagonal).
+//
+// If energy descent is verified (see HopfieldNetworkBuilder.SetVerifyEnergyDescent), CheckedUpdates is the number of unit updates that
+// changed a unit (and so were checked), EnergyViolations the number of those updates that increased the energy -1/2 x^T W x of the
+// state, and MaximumEnergyIncrease the largest increase. Otherwise all three are 0. In the binary domain this energy differs from
+// the energy of SweepEnergies, see lyapunovEnergy.
+// History holds the recorded steps of the relaxation, and is empty unless intensive data collection is allowed
+// (see RelaxationHistoryPolicy for the steps recorded).
 type RelaxationResult struct {
-	Stable             bool
-	DistancesToTargets []float64
-	StateHistory       []*mat.VecDense
-	EnergyHistory      [][]float64
+	Stable                bool
+	NumSteps              int
+	FinalState            *mat.VecDense
+	FinalEnergyProfile    []float64
+	FinalOverlaps         []float64
+	DistancesToTargets    []float64
+	NearestTargetIndex    int
+	MinimumDistance       float64
+	ExactRecall           bool
+	InverseRecall         bool
+	OriginTargetIndex     int
+	InitialFlippedUnits   int
+	FlippedUnits          int
+	Sweeps                int
+	UnitFlips             int
+	SweepEnergies         []float64
+	EnergyDecrease        float64
+	EnergyIncreased       bool
+	CheckedUpdates        int
+	EnergyViolations      int
+	MaximumEnergyIncrease float64
+	History               []*RelaxationHistoryStep
+}
+
+// A single recorded step of the relaxation of a state.
+//
+// StepIndex is the index of the step, where step 0 is the initial state.
+// State (a copy) and EnergyProfile are only recorded if the history policy does not record only overlaps.
+// Overlaps are the overlaps of the state with each target state, and are only recorded if the history policy records only overlaps.
+type RelaxationHistoryStep struct {
+	StepIndex     int
+	State         *mat.VecDense
+	EnergyProfile []float64
+	Overlaps      []float64
 }
 
 // Update a state one step in a randomly permuted ordering of units.
@@ -292,6 +423,9 @@ func (network *HopfieldNetwork) UpdateState(state *mat.VecDense) {
 
 // Relax a state by updating until the number of unstable units is below the threshold defined by the network.
 //
+// If intensive data collection is allowed the history of the relaxation is recorded, according to the step interval
+// and overlap settings of the relaxation history policy.
+//
 // # Arguments
 //
 // state *mat.VecDense: The vector to relax. Note the vector is altered in place to avoid allocating new memory.
@@ -300,62 +434,170 @@ func (network *HopfieldNetwork) UpdateState(state *mat.VecDense) {
 //
 // A RelaxationResult, representing the result of relaxing a specific state.
 func (network *HopfieldNetwork) RelaxState(state *mat.VecDense) *RelaxationResult {
-	// We create a list of unit indices to use for randomly updating units
-	unitIndices := network.getUnitIndices()
-	stateHistory := make([]*mat.VecDense, network.maximumRelaxationIterations+1)
-	energyHistory := make([][]float64, network.maximumRelaxationIterations+1)
-	// Only collect data on histories if allowed, as otherwise very intensive
-	if network.allowIntensiveDataCollection {
-		stateHistory[0] = mat.VecDenseCopyOf(state)
-		energyHistory[0] = network.AllUnitEnergies(state)
+	return network.relaxState(state, network.getUnitIndices(), network.allowIntensiveDataCollection)
+}
+
+// Relax a state in place, shared by RelaxState and the concurrent relaxation goroutines.
+//
+// The state is only copied for recorded history steps (and once for the final state), so relaxing without recording history
+// allocates very little.
+//
+// # Arguments
+//
+// state *mat.VecDense: The vector to relax, altered in place.
+//
+// unitIndices []int: A list of all unit indices, shuffled to randomly order the unit updates. Reused between relaxations.
+//
+// recordHistory bool: Flag to record the history of this relaxation.
+//
+// # Returns
+//
+// A RelaxationResult, representing the result of relaxing the state.
+func (network *HopfieldNetwork) relaxState(state *mat.VecDense, unitIndices []int, recordHistory bool) *RelaxationResult {
+	result := &RelaxationResult{
+		SweepEnergies: []float64{network.StateEnergy(state)},
+		History:       []*RelaxationHistoryStep{},
+	}
+	if recordHistory {
+		result.History = append(result.History, network.newRelaxationHistoryStep(0, state))
+	}
+
+	// The energy of the state after the last checked unit update, if energy descent is verified
+	currentEnergy := 0.0
+	if network.verifyEnergyDescent {
+		currentEnergy = network.lyapunovEnergy(state)
 	}
 
-	// We will loop up to the maximum number of iterations, only returning early if the state is stable
-	for stepIndex := 1; stepIndex <= network.maximumRelaxationIterations; stepIndex++ {
-		network.logger.Printf("RelaxState stepIndex: %v\n", stepIndex)
+	// We will loop up to the maximum number of iterations, only stopping early if the state is stable
+	stepIndex := 0
+	for stepIndex < network.maximumRelaxationIterations {
+		stepIndex += 1
 
 		hopfieldutils.ShuffleList(network.randomGenerator, unitIndices)
 		for _, unitIndex := range unitIndices {
 			matrixTargetRow := network.matrix.RowView(unitIndex)
 			unitActivity := mat.Dot(matrixTargetRow, state)
 			unitValue := network.domainManager.ActivationFunctionUnit(unitActivity)
+			if unitValue == state.AtVec(unitIndex) {
+				continue
+			}
+			result.UnitFlips += 1
 			state.SetVec(unitIndex, unitValue)
+
+			if network.verifyEnergyDescent {
+				updateEnergy := network.lyapunovEnergy(state)
+				result.CheckedUpdates += 1
+				if energyIncrease := updateEnergy - currentEnergy; energyIncrease > RELAXATION_ENERGY_TOLERANCE {
+					result.EnergyViolations += 1
+					result.MaximumEnergyIncrease = math.Max(result.MaximumEnergyIncrease, energyIncrease)
+				}
+				currentEnergy = updateEnergy
+			}
 		}
 		network.domainManager.ActivationFunction(state)
 
-		// Collect the current history item if requested
-		if network.allowIntensiveDataCollection || network.StateIsStable(state) {
-			stateHistory[stepIndex] = mat.VecDenseCopyOf(state)
-			energyHistory[stepIndex] = network.AllUnitEnergies(state)
+		sweepEnergy := network.StateEnergy(state)
+		if sweepEnergy > result.SweepEnergies[len(result.SweepEnergies)-1]+RELAXATION_ENERGY_TOLERANCE {
+			result.EnergyIncreased = true
 		}
+		result.SweepEnergies = append(result.SweepEnergies, sweepEnergy)
 
 		// Here we check the unit energies, counting how many unstable units there are (E>0)
-		// and returning true (stable) if the number of unstable units is less than or equal to
+		// and stopping (stable) if the number of unstable units is less than or equal to
 		// the network parameter set from the builder
 		if network.StateIsStable(state) {
-			result := RelaxationResult{
-				Stable:             true,
-				DistancesToTargets: distancemeasure.MeasureDistancesToCollection(network.targetStates, state, network.distanceMeasure),
-				StateHistory:       stateHistory[:stepIndex+1],
-				EnergyHistory:      energyHistory[:stepIndex+1],
-			}
-			return &result
+			result.Stable = true
+			break
+		}
+
+		if recordHistory && network.relaxationHistoryPolicy.recordsStep(stepIndex) {
+			result.History = append(result.History, network.newRelaxationHistoryStep(stepIndex, state))
 		}
 	}
 
-	// If we have reached this statement we have iterated the maximum number of times
-	// and the state is STILL not stable. We return false to show the state is unstable
+	// The final step is always recorded, unless it already has been
+	if recordHistory && result.History[len(result.History)-1].StepIndex != stepIndex {
+		result.History = append(result.History, network.newRelaxationHistoryStep(stepIndex, state))
+	}
+
+	result.NumSteps = stepIndex + 1
+	result.Sweeps = stepIndex
+	result.EnergyDecrease = result.SweepEnergies[0] - result.SweepEnergies[len(result.SweepEnergies)-1]
+	result.FinalState = mat.VecDenseCopyOf(state)
+	result.FinalEnergyProfile = network.AllUnitEnergies(state)
+	result.FinalOverlaps = network.targetOverlaps(state)
+	result.DistancesToTargets = distancemeasure.MeasureDistancesToCollection(network.targetStates, state, network.distanceMeasure)
+	network.measureRecall(result)
+	return result
+}
 
-	// If we have reached this point we MUST collect the final state and energy manually
-	stateHistory[len(stateHistory)-1] = mat.VecDenseCopyOf(state)
-	energyHistory[len(energyHistory)-1] = network.AllUnitEnergies(state)
-	result := RelaxationResult{
-		Stable:             false,
-		DistancesToTargets: distancemeasure.MeasureDistancesToCollection(network.targetStates, state, network.distanceMeasure),
-		StateHistory:       stateHistory,
-		EnergyHistory:      energyHistory,
+// Set the recall metrics of a relaxation result (the nearest target state, and if that target state is recalled exactly)
+// from the final state and distances to the target states. The origin target metrics are reset, as they are only known
+// for states generated from a target state.
+func (network *HopfieldNetwork) measureRecall(result *RelaxationResult) {
+	result.NearestTargetIndex = -1
+	result.MinimumDistance = -1
+	result.OriginTargetIndex = -1
+	result.InitialFlippedUnits = -1
+	result.FlippedUnits = -1
+	if len(result.DistancesToTargets) == 0 {
+		return
+	}
+
+	for targetIndex, distance := range result.DistancesToTargets {
+		if result.NearestTargetIndex == -1 || distance < result.MinimumDistance {
+			result.NearestTargetIndex = targetIndex
+			result.MinimumDistance = distance
+		}
+	}
+
+	nearestTargetState := network.targetStates[result.NearestTargetIndex]
+	if mat.Equal(result.FinalState, nearestTargetState) {
+		result.ExactRecall = true
+		return
+	}
+	inverseFinalState := mat.VecDenseCopyOf(result.FinalState)
+	network.domainManager.InvertState(inverseFinalState)
+	if mat.Equal(inverseFinalState, nearestTargetState) {
+		result.ExactRecall = true
+		result.InverseRecall = true
 	}
-	return &result
+}
+
+// Record a single step of a relaxation, copying the state only if the history policy records full states.
+func (network *HopfieldNetwork) newRelaxationHistoryStep(stepIndex int, state *mat.VecDense) *RelaxationHistoryStep {
+	if network.relaxationHistoryPolicy.OverlapsOnly {
+		return &RelaxationHistoryStep{
+			StepIndex: stepIndex,
+			Overlaps:  network.targetOverlaps(state),
+		}
+	}
+	return &RelaxationHistoryStep{
+		StepIndex:     stepIndex,
+		State:         mat.VecDenseCopyOf(state),
+		EnergyProfile: network.AllUnitEnergies(state),
+	}
+}
+
+// Get the overlap of a state with each target state, see distancemeasure.Overlap.
+//
+// An overlap of 1 means the state is the target state, and -1 that the state is the inverse of the target state.
+func (network *HopfieldNetwork) targetOverlaps(state *mat.VecDense) []float64 {
+	return distancemeasure.MeasureOverlapsToCollection(network.targetStates, state, network.domain)
+}
+
+// Determine if a state is one of the target states, or the inverse of a target state.
+//
+// A state that is stable but not a target state (or inverse) is a spurious state.
+func (network *HopfieldNetwork) isTargetState(state *mat.VecDense) bool {
+	inverseState := mat.VecDenseCopyOf(state)
+	network.domainManager.InvertState(inverseState)
+	for _, targetState := range network.targetStates {
+		if mat.Equal(targetState, state) || mat.Equal(targetState, inverseState) {
+			return true
+		}
+	}
+	return false
 }
 
 // Defines a thread-orientated approach to relaxing states. Useful if the number of states to update
@@ -368,75 +610,19 @@ func (network *HopfieldNetwork) RelaxState(state *mat.VecDense) *RelaxationResul
 // stateChannel: A channel to pass the next state to be updated to the goroutine. This channel should be created and passed before the goroutines are created.
 //
 // resultChannel: A channel to pass the result of the relaxation back to the master thread.
-func (network *HopfieldNetwork) concurrentRelaxStateRoutine(stateChannel chan *hopfieldutils.IndexedWrapper[*mat.VecDense], resultChannel chan *hopfieldutils.IndexedWrapper[RelaxationResult]) {
+//
+// recordedProbes: Flags for each state index, true if the history of relaxing that state is recorded. Must not be altered while the goroutine runs.
+func (network *HopfieldNetwork) concurrentRelaxStateRoutine(stateChannel chan *hopfieldutils.IndexedWrapper[*mat.VecDense], resultChannel chan *hopfieldutils.IndexedWrapper[*RelaxationResult], recordedProbes []bool) {
 	// We create a list of unit indices to use for randomly updating units
 	// Each goroutine gets a copy so they can work independently
 	unitIndices := network.getUnitIndices()
-	var state *mat.VecDense
 
 	// This loop will take an indexed state from the channel until the channel is closed by the sender.
 	// That is our terminating condition
-	//
-	// We name this loop so we can continue directly if the state is already stable.
-StateRecvLoop:
 	for wrappedState := range stateChannel {
-		state = wrappedState.Data
-		stateHistory := make([]*mat.VecDense, network.maximumRelaxationIterations+1)
-		energyHistory := make([][]float64, network.maximumRelaxationIterations+1)
-		// Only collect data on histories if allowed, as otherwise very intensive
-		if network.allowIntensiveDataCollection {
-			stateHistory[0] = mat.VecDenseCopyOf(state)
-			energyHistory[0] = network.AllUnitEnergies(state)
-		}
-
-		for stepIndex := 1; stepIndex <= network.maximumRelaxationIterations; stepIndex++ {
-			hopfieldutils.ShuffleList(network.randomGenerator, unitIndices)
-			for _, unitIndex := range unitIndices {
-				matrixTargetRow := network.matrix.RowView(unitIndex)
-				unitActivity := mat.Dot(matrixTargetRow, state)
-				unitValue := network.domainManager.ActivationFunctionUnit(unitActivity)
-				state.SetVec(unitIndex, unitValue)
-			}
-			network.domainManager.ActivationFunction(state)
-
-			// Collect the current history item if requested
-			if network.allowIntensiveDataCollection || network.StateIsStable(state) {
-				stateHistory[stepIndex] = mat.VecDenseCopyOf(state)
-				energyHistory[stepIndex] = network.AllUnitEnergies(state)
-			}
-
-			if network.StateIsStable(state) {
-				result := RelaxationResult{
-					Stable:             true,
-					DistancesToTargets: distancemeasure.MeasureDistancesToCollection(network.targetStates, state, network.distanceMeasure),
-					StateHistory:       stateHistory[:stepIndex+1],
-					EnergyHistory:      energyHistory[:stepIndex+1],
-				}
-
-				resultChannel <- &hopfieldutils.IndexedWrapper[RelaxationResult]{
-					Index: wrappedState.Index,
-					Data:  result,
-				}
-				// We need not carry on with this state - continue and get the next one
-				continue StateRecvLoop
-			} // if unstable
-		} // for iterationIndex
-
-		// If we reach this then we did not relax correctly
-
-		// If we have reached this point we MUST collect the final state and energy manually
-		stateHistory[len(stateHistory)-1] = mat.VecDenseCopyOf(state)
-		energyHistory[len(energyHistory)-1] = network.AllUnitEnergies(state)
-		result := RelaxationResult{
-			Stable:             false,
-			DistancesToTargets: distancemeasure.MeasureDistancesToCollection(network.targetStates, state, network.distanceMeasure),
-			StateHistory:       stateHistory,
-			EnergyHistory:      energyHistory,
-		}
-
-		resultChannel <- &hopfieldutils.IndexedWrapper[RelaxationResult]{
+		resultChannel <- &hopfieldutils.IndexedWrapper[*RelaxationResult]{
 			Index: wrappedState.Index,
-			Data:  result,
+			Data:  network.relaxState(wrappedState.Data, unitIndices, recordedProbes[wrappedState.Index]),
 		}
 	}
 }
@@ -445,6 +631,9 @@ StateRecvLoop:
 //
 // This method works concurrently, and is the most (time) efficient way to relax a large number of states.
 //
+// A RelaxationResult event is sent to the data collector for each state (and RelaxationHistory events for each step,
+// if intensive data collection is allowed), with the index of the state in the given slice.
+//
 // TODO: Currently all states are dispatched before results are processed. This is done by having a large enough buffer to
 // hold ALL the results. It would be MUCH better to process results in a select statement along with dispatching new states.
 //
@@ -459,13 +648,61 @@ StateRecvLoop:
 //
 // A slice of RelaxationResult, each representing the result of relaxing a specific state.
 func (network *HopfieldNetwork) ConcurrentRelaxStates(states []*mat.VecDense, numThreads int) []*RelaxationResult {
+	return network.concurrentRelaxStates(states, nil, numThreads)
+}
+
+// Relaxes a set of states that were each generated from a target state of the network (e.g. by flipping some units of the target state).
+//
+// This method is the same as ConcurrentRelaxStates, but the results also measure the recall of the target state each state was
+// generated from: the number of units differing from that target state before and after relaxation.
+//
+// # Arguments
+//
+// states []*mat.VecDense: A slice of states that are to be relaxed. The order of this slice corresponds to the order of the returned results.
+//
+// originTargetIndices []int: The index of the target state each state was generated from. Must have the same length as states.
+//
+// numThreads int: An integer determining how many threads to run.
+//
+// # Returns
+//
+// A slice of RelaxationResult, each representing the result of relaxing a specific state.
+func (network *HopfieldNetwork) ConcurrentRelaxStatesFromTargets(states []*mat.VecDense, originTargetIndices []int, numThreads int) []*RelaxationResult {
+	if len(originTargetIndices) != len(states) {
+		panic("HopfieldNetwork encountered an error during relaxation! There must be exactly one origin target index for each state!")
+	}
+	for _, targetIndex := range originTargetIndices {
+		if targetIndex < 0 || targetIndex >= len(network.targetStates) {
+			panic("HopfieldNetwork encountered an error during relaxation! Origin target indices must index a target state of the network!")
+		}
+	}
+	return network.concurrentRelaxStates(states, originTargetIndices, numThreads)
+}
+
+// Relax a set of states concurrently, shared by ConcurrentRelaxStates and ConcurrentRelaxStatesFromTargets.
+//
+// If originTargetIndices is not nil the origin target metrics of each result are measured. As states are relaxed in place,
+// the units differing from the origin target state before relaxation are counted before any state is dispatched.
+func (network *HopfieldNetwork) concurrentRelaxStates(states []*mat.VecDense, originTargetIndices []int, numThreads int) []*RelaxationResult {
+	hammingDistance := distancemeasure.GetHammingDistance()
+	initialFlippedUnits := make([]int, len(originTargetIndices))
+	for stateIndex, targetIndex := range originTargetIndices {
+		initialFlippedUnits[stateIndex] = int(hammingDistance(states[stateIndex], network.targetStates[targetIndex]))
+	}
+
 	stateChannel := make(chan *hopfieldutils.IndexedWrapper[*mat.VecDense], numThreads*10)
-	resultChannel := make(chan *hopfieldutils.IndexedWrapper[RelaxationResult], len(states))
+	resultChannel := make(chan *hopfieldutils.IndexedWrapper[*RelaxationResult], len(states))
 	results := make([]*RelaxationResult, len(states))
 
+	// Select the recorded probes before starting the goroutines, as they share the random generator
+	recordedProbes := make([]bool, len(states))
+	if network.allowIntensiveDataCollection {
+		recordedProbes = network.relaxationHistoryPolicy.selectProbes(len(states), network.randomGenerator)
+	}
+
 	// Start all the concurrent channels
 	for i := 0; i < numThreads; i++ {
-		go network.concurrentRelaxStateRoutine(stateChannel, resultChannel)
+		go network.concurrentRelaxStateRoutine(stateChannel, resultChannel, recordedProbes)
 	}
 
 	bar := progressbar.Default(int64(len(states)))
@@ -479,12 +716,82 @@ func (network *HopfieldNetwork) ConcurrentRelaxStates(states []*mat.VecDense, nu
 
 	resultsReceived := 0
 	for wrappedResult := range resultChannel {
-		results[wrappedResult.Index] = &wrappedResult.Data
+		results[wrappedResult.Index] = wrappedResult.Data
 		resultsReceived++
 
 		if resultsReceived >= len(states) {
 			break
 		}
 	}
+
+	for stateIndex, targetIndex := range originTargetIndices {
+		results[stateIndex].OriginTargetIndex = targetIndex
+		results[stateIndex].InitialFlippedUnits = initialFlippedUnits[stateIndex]
+		results[stateIndex].FlippedUnits = int(hammingDistance(results[stateIndex].FinalState, network.targetStates[targetIndex]))
+	}
+
+	network.emitRelaxationResults(results)
 	return results
 }
+
+// Send the RelaxationResult (and, if intensive data collection is allowed, RelaxationHistory) events
+// of a collection of relaxation results to the data collector.
+//
+// The index of each result is taken as the index of the relaxed state. If the relaxation history policy records
+// only spurious states, the history of results relaxing to a target state (or inverse) is not sent.
+func (network *HopfieldNetwork) emitRelaxationResults(results []*RelaxationResult) {
+	collectResults := network.dataCollector.IsSubscribed(datacollector.DataCollectionEvent_RelaxationResult)
+	collectHistory := network.allowIntensiveDataCollection && network.dataCollector.IsSubscribed(datacollector.DataCollectionEvent_RelaxationHistory)
+	if !collectResults && !collectHistory {
+		return
+	}
+
+	bar := progressbar.Default(int64(len(results)), "SAVING RELAXATION RESULTS")
+	for stateIndex, result := range results {
+		bar.Add(1)
+		attractorClassification := ClassifyAttractor(result.FinalState, network.targetStates, network.domain)
+		network.dataCollector.Emit(&datacollector.RelaxationResultData{
+			StateIndex:          stateIndex,
+			Stable:              result.Stable,
+			NumSteps:            result.NumSteps,
+			FinalState:          result.FinalState.RawVector().Data,
+			DistancesToTargets:  result.DistancesToTargets,
+			EnergyProfile:       result.FinalEnergyProfile,
+			Overlaps:            result.FinalOverlaps,
+			NearestTargetIndex:  result.NearestTargetIndex,
+			MinimumDistance:     result.MinimumDistance,
+			ExactRecall:         result.ExactRecall,
+			InverseRecall:       result.InverseRecall,
+			OriginTargetIndex:   result.OriginTargetIndex,
+			InitialFlippedUnits: result.InitialFlippedUnits,
+			FlippedUnits:        result.FlippedUnits,
+			Sweeps:              result.Sweeps,
+			UnitFlips:           result.UnitFlips,
+			SweepEnergies:       result.SweepEnergies,
+			EnergyDecrease:      result.EnergyDecrease,
+			EnergyIncreased:     result.EnergyIncreased,
+			AttractorClass:      attractorClassification.Class.String(),
+			MixtureTargets:      attractorClassification.MixtureTargets,
+			MixtureSigns:        attractorClassification.MixtureSigns,
+		})
+
+		if !collectHistory || len(result.History) == 0 {
+			continue
+		}
+		if network.relaxationHistoryPolicy.SpuriousOnly && network.isTargetState(result.FinalState) {
+			continue
+		}
+		for _, historyStep := range result.History {
+			historyData := &datacollector.RelaxationHistoryData{
+				StateIndex: stateIndex,
+				StepIndex:  historyStep.StepIndex,
+				Overlaps:   historyStep.Overlaps,
+			}
+			if historyStep.State != nil {
+				historyData.State = historyStep.State.RawVector().Data
+				historyData.EnergyProfile = historyStep.EnergyProfile
+			}
+			network.dataCollector.Emit(historyData)
+		}
+	}
+}
@@ -19,6 +19,7 @@ type HopfieldNetworkBuilder struct {
 	domain                         domain.DomainEnum
 	forceSymmetric                 bool
 	forceZeroDiagonal              bool
+	distanceMeasure                distancemeasure.DistanceMeasureEnum
 	learningMethod                 LearningMethod
 	learningRule                   LearningRule
 	epochs                         int
@@ -26,11 +27,19 @@ type HopfieldNetworkBuilder struct {
 	maximumRelaxationIterations    int
 	learningRate                   float64
 	learningNoiseMethod            noiseapplication.NoiseApplicationMethod
+	learningNoiseMethodEnum        noiseapplication.NoiseApplicationEnum
 	learningNoiseScale             float64
 	unitsUpdatedPerStep            int
 	dataCollector                  *datacollector.DataCollector
 	logger                         *log.Logger
 	allowIntensiveDataCollection   bool
+	relaxationHistoryPolicy        RelaxationHistoryPolicy
+	learnEpochEigenvalues          int
+	matrixSnapshotInterval         int
+	verifyEnergyDescent            bool
+	seed                           uint64
+	initialMatrix                  *mat.Dense
+	targetStates                   []*mat.VecDense
 }
 
 // Get a new HopfieldNetworkBuilder filled with the default values.
@@ -44,6 +53,7 @@ func NewHopfieldNetworkBuilder() *HopfieldNetworkBuilder {
 		domain:                         domain.BipolarDomain,
 		forceSymmetric:                 true,
 		forceZeroDiagonal:              true,
+		distanceMeasure:                distancemeasure.ManhattanDistanceWithInversion,
 		maximumRelaxationUnstableUnits: 0,
 		maximumRelaxationIterations:    100,
 		learningRate:                   1.0,
@@ -51,6 +61,11 @@ func NewHopfieldNetworkBuilder() *HopfieldNetworkBuilder {
 		dataCollector:                  datacollector.NewDataCollector(),
 		logger:                         log.Default(),
 		allowIntensiveDataCollection:   false,
+		relaxationHistoryPolicy:        DefaultRelaxationHistoryPolicy(),
+		learnEpochEigenvalues:          0,
+		matrixSnapshotInterval:         0,
+		verifyEnergyDescent:            false,
+		seed:                           0,
 	}
 }
 
@@ -97,6 +112,16 @@ func (networkBuilder *HopfieldNetworkBuilder) SetForceZeroDiagonal(zeroDiagonalF
 	return networkBuilder
 }
 
+// Set the distance measure used to measure the distance from relaxed states to each target state.
+//
+// Defaults to ManhattanDistanceWithInversion. See distancemeasure.DistanceMeasureEnum for the options.
+//
+// Note this method returns the builder pointer so chained calls can be used.
+func (networkBuilder *HopfieldNetworkBuilder) SetDistanceMeasure(distanceMeasure distancemeasure.DistanceMeasureEnum) *HopfieldNetworkBuilder {
+	networkBuilder.distanceMeasure = distanceMeasure
+	return networkBuilder
+}
+
 // Set the learning method of this network based on the LearningMethodEnum selected.
 //
 // Note this method returns the builder pointer so chained calls can be used.
@@ -162,12 +187,14 @@ func (networkBuilder *HopfieldNetworkBuilder) SetLearningRate(learningRate float
 // Note this method returns the builder pointer so chained calls can be used.
 func (networkBuilder *HopfieldNetworkBuilder) SetLearningNoiseMethod(learningNoiseMethod noiseapplication.NoiseApplicationEnum) *HopfieldNetworkBuilder {
 	networkBuilder.learningNoiseMethod = noiseapplication.GetNoiseApplicationMethod(learningNoiseMethod)
+	networkBuilder.learningNoiseMethodEnum = learningNoiseMethod
 	return networkBuilder
 }
 
-// Set the learning noise ratio. This is the number of elements that are inverted in each state before relaxation.
+// Set the learning noise ratio. This is the number of elements that are inverted in each state before relaxation,
+// or the standard deviation of the noise for GaussianApplication.
 //
-// Defaults to 0.0. Must be in the range [0.0, 1.0] but should be a small value (e.g. <0.25)
+// Defaults to 0.0. Must not be negative. For the inversion methods this must be in the range [0.0, 1.0] but should be a small value (e.g. <0.25)
 //
 // Note this method returns the builder pointer so chained calls can be used.
 func (networkBuilder *HopfieldNetworkBuilder) SetLearningNoiseRatio(learningNoiseRatio float64) *HopfieldNetworkBuilder {
@@ -210,6 +237,88 @@ func (networkBuilder *HopfieldNetworkBuilder) SetAllowIntensiveDataCollection(al
 	return networkBuilder
 }
 
+// Set the policy selecting which relaxation history is recorded when intensive data collection is allowed.
+//
+// Defaults to DefaultRelaxationHistoryPolicy, recording every step of every probe state.
+// See RelaxationHistoryPolicy for the ways the history can be reduced.
+//
+// Note this method returns the builder pointer so chained calls can be used.
+func (networkBuilder *HopfieldNetworkBuilder) SetRelaxationHistoryPolicy(relaxationHistoryPolicy RelaxationHistoryPolicy) *HopfieldNetworkBuilder {
+	networkBuilder.relaxationHistoryPolicy = relaxationHistoryPolicy
+	return networkBuilder
+}
+
+// Set the number of eigenvalues of the weight matrix computed after each epoch of learning, sent in the LearnEpoch events.
+//
+// Defaults to 0, computing no eigenvalues. The eigendecomposition is expensive for large networks, and is only computed
+// if the data collector is subscribed to LearnEpoch events.
+//
+// Note this method returns the builder pointer so chained calls can be used.
+func (networkBuilder *HopfieldNetworkBuilder) SetLearnEpochEigenvalues(learnEpochEigenvalues int) *HopfieldNetworkBuilder {
+	networkBuilder.learnEpochEigenvalues = learnEpochEigenvalues
+	return networkBuilder
+}
+
+// Set the number of epochs between snapshots of the weight matrix, sent in MatrixSnapshot events.
+//
+// Defaults to 0, taking no snapshots. Otherwise a snapshot is taken after epochs 0, N, 2N, ...
+// Snapshots (like the statistics of the LearnEpoch events) are taken before the matrix is normalized at the end of learning
+// (by its Frobenius norm), so even a snapshot of the last epoch differs from the final matrix by that constant factor.
+//
+// Note this method returns the builder pointer so chained calls can be used.
+func (networkBuilder *HopfieldNetworkBuilder) SetMatrixSnapshotInterval(matrixSnapshotInterval int) *HopfieldNetworkBuilder {
+	networkBuilder.matrixSnapshotInterval = matrixSnapshotInterval
+	return networkBuilder
+}
+
+// Set the flag to verify that relaxation never increases the energy of a state.
+//
+// If true, the energy -1/2 x^T W x of the state is computed after every unit update that changes a unit, and any increase is
+// counted as a violation in the RelaxationResult. Asynchronous updates are only guaranteed to descend this energy for a symmetric
+// weight matrix with a non-negative diagonal, so this diagnoses networks built with SetForceSymmetric(false) or SetForceZeroDiagonal(false).
+// In the binary domain the energy is taken over the binary state, rather than the bipolar mapped state of StateEnergy, as only
+// the former is descended by the binary update. Each check computes the full state energy, so verification is expensive for large networks.
+//
+// Defaults to false.
+//
+// Note this method returns the builder pointer so chained calls can be used.
+func (networkBuilder *HopfieldNetworkBuilder) SetVerifyEnergyDescent(verifyEnergyDescent bool) *HopfieldNetworkBuilder {
+	networkBuilder.verifyEnergyDescent = verifyEnergyDescent
+	return networkBuilder
+}
+
+// Set the seed of the random generator used by the network (for random matrix initialization, learning noise, and unit update order).
+//
+// If the seed is left at the default value (0) then a seed is selected from the current time.
+//
+// Note this method returns the builder pointer so chained calls can be used.
+func (networkBuilder *HopfieldNetworkBuilder) SetSeed(seed uint64) *HopfieldNetworkBuilder {
+	networkBuilder.seed = seed
+	return networkBuilder
+}
+
+// Set the weight matrix the network starts with, for example a matrix saved from a previously trained network.
+//
+// The matrix is copied, so later changes to the given matrix do not affect the network.
+// If set, this overrides randMatrixInit. The matrix must be square with size equal to the network dimension.
+//
+// Note this method returns the builder pointer so chained calls can be used.
+func (networkBuilder *HopfieldNetworkBuilder) SetMatrix(matrix *mat.Dense) *HopfieldNetworkBuilder {
+	networkBuilder.initialMatrix = matrix
+	return networkBuilder
+}
+
+// Set the target states the network has already learned, for example when loading a previously trained network.
+//
+// These states are not learned during Build, but are used to measure distances to targets during relaxation
+// (and are returned by GetLearnedStates). Each state must have length equal to the network dimension.
+//
+// Note this method returns the builder pointer so chained calls can be used.
+func (networkBuilder *HopfieldNetworkBuilder) SetTargetStates(targetStates []*mat.VecDense) *HopfieldNetworkBuilder {
+	networkBuilder.targetStates = targetStates
+	return networkBuilder
+}
+
 // Build and return a new HopfieldNetwork using the parameters specified with builder methods.
 func (networkBuilder *HopfieldNetworkBuilder) Build() *HopfieldNetwork {
 	if networkBuilder.dimension <= 0 {
@@ -220,19 +329,70 @@ func (networkBuilder *HopfieldNetworkBuilder) Build() *HopfieldNetwork {
 		panic("HopfieldNetworkBuilder encountered an error during build! Epochs must be a positive integer!")
 	}
 
-	if networkBuilder.learningNoiseScale < 0.0 || networkBuilder.learningNoiseScale > 1.0 {
-		panic("HopfieldNetworkBuilder encountered an error during build! learningNoiseRatio must be in range [0.0, 1.0]!")
+	if networkBuilder.learningNoiseScale < 0.0 {
+		panic("HopfieldNetworkBuilder encountered an error during build! learningNoiseRatio must not be negative!")
+	}
+
+	invertsUnits := networkBuilder.learningNoiseMethodEnum == noiseapplication.MaximalInversion ||
+		networkBuilder.learningNoiseMethodEnum == noiseapplication.RandomSubMaximalInversion
+	if invertsUnits && networkBuilder.learningNoiseScale > 1.0 {
+		panic("HopfieldNetworkBuilder encountered an error during build! learningNoiseRatio must be in range [0.0, 1.0] for inversion noise methods!")
 	}
 
 	if networkBuilder.unitsUpdatedPerStep < 0 || networkBuilder.unitsUpdatedPerStep > networkBuilder.dimension {
 		panic("HopfieldNetworkBuilder encountered an error during build! unitsUpdatedPerStep must be a positive integer that is smaller than the network dimension!")
 	}
 
-	randSrc := rand.NewSource((uint64(time.Now().UnixNano())))
+	if networkBuilder.relaxationHistoryPolicy.StepInterval < 1 {
+		panic("HopfieldNetworkBuilder encountered an error during build! The relaxation history step interval must be a positive integer!")
+	}
+
+	if networkBuilder.relaxationHistoryPolicy.SampleSize < 0 {
+		panic("HopfieldNetworkBuilder encountered an error during build! The relaxation history sample size must not be negative!")
+	}
+
+	for _, probeIndex := range networkBuilder.relaxationHistoryPolicy.ProbeIndices {
+		if probeIndex < 0 {
+			panic("HopfieldNetworkBuilder encountered an error during build! The relaxation history probe indices must not be negative!")
+		}
+	}
+
+	if networkBuilder.learnEpochEigenvalues < 0 {
+		panic("HopfieldNetworkBuilder encountered an error during build! The number of learn epoch eigenvalues must not be negative!")
+	}
+
+	if _, err := distancemeasure.ParseDistanceMeasureEnum(networkBuilder.distanceMeasure.String()); err != nil {
+		panic("HopfieldNetworkBuilder encountered an error during build! The distance measure is not a valid DistanceMeasureEnum!")
+	}
+
+	if networkBuilder.matrixSnapshotInterval < 0 {
+		panic("HopfieldNetworkBuilder encountered an error during build! The matrix snapshot interval must not be negative!")
+	}
+
+	seed := networkBuilder.seed
+	if seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+	randSrc := rand.NewSource(seed)
 	randomGenerator := rand.New(randSrc)
 
+	if networkBuilder.initialMatrix != nil {
+		rows, cols := networkBuilder.initialMatrix.Dims()
+		if rows != networkBuilder.dimension || cols != networkBuilder.dimension {
+			panic("HopfieldNetworkBuilder encountered an error during build! The initial matrix must be square with size equal to the network dimension!")
+		}
+	}
+
+	for _, targetState := range networkBuilder.targetStates {
+		if targetState.Len() != networkBuilder.dimension {
+			panic("HopfieldNetworkBuilder encountered an error during build! Target states must have length equal to the network dimension!")
+		}
+	}
+
 	var matrix *mat.Dense
-	if networkBuilder.randMatrixInit {
+	if networkBuilder.initialMatrix != nil {
+		matrix = mat.DenseCopyOf(networkBuilder.initialMatrix)
+	} else if networkBuilder.randMatrixInit {
 		normalDistribution := distuv.Normal{
 			Mu:    0,
 			Sigma: 0.01,
@@ -251,7 +411,7 @@ func (networkBuilder *HopfieldNetworkBuilder) Build() *HopfieldNetwork {
 	}
 
 	domainManager := domain.GetDomainManager(networkBuilder.domain)
-	distanceMeasure := distancemeasure.GetManhattanDistanceWithInversion(domainManager)
+	distanceMeasure := distancemeasure.GetDistanceMeasure(networkBuilder.distanceMeasure, networkBuilder.domain)
 
 	return &HopfieldNetwork{
 		matrix:                         matrix,
@@ -265,6 +425,7 @@ func (networkBuilder *HopfieldNetworkBuilder) Build() *HopfieldNetwork {
 		learningRule:                   networkBuilder.learningRule,
 		epochs:                         networkBuilder.epochs,
 		randomGenerator:                randomGenerator,
+		targetStates:                   networkBuilder.targetStates,
 		maximumRelaxationUnstableUnits: networkBuilder.maximumRelaxationUnstableUnits,
 		maximumRelaxationIterations:    networkBuilder.maximumRelaxationIterations,
 		learningRate:                   networkBuilder.learningRate,
@@ -274,6 +435,10 @@ func (networkBuilder *HopfieldNetworkBuilder) Build() *HopfieldNetwork {
 		dataCollector:                  networkBuilder.dataCollector,
 		logger:                         networkBuilder.logger,
 		allowIntensiveDataCollection:   networkBuilder.allowIntensiveDataCollection,
+		relaxationHistoryPolicy:        networkBuilder.relaxationHistoryPolicy,
+		learnEpochEigenvalues:          networkBuilder.learnEpochEigenvalues,
+		matrixSnapshotInterval:         networkBuilder.matrixSnapshotInterval,
+		verifyEnergyDescent:            networkBuilder.verifyEnergyDescent,
 	}
 
 }
@@ -2,6 +2,7 @@ package hopfieldnetwork
 
 import (
 	"hmcalister/hopfield/hopfieldnetwork/datacollector"
+	"hmcalister/hopfield/hopfieldutils"
 
 	"github.com/schollz/progressbar/v3"
 	"gonum.org/v1/gonum/mat"
@@ -24,6 +25,32 @@ const (
 	IterativeBatchMethod LearningMethodEnum = iota
 )
 
+// All valid LearningMethodEnum values, used when parsing learning methods from strings.
+var learningMethodEnumValues = []LearningMethodEnum{
+	FullSetMethod,
+	IterativeBatchMethod,
+}
+
+// Parse a LearningMethodEnum from either its name (e.g. "FullSetMethod", case insensitive) or its integer value.
+func ParseLearningMethodEnum(name string) (LearningMethodEnum, error) {
+	return hopfieldutils.ParseEnum(name, learningMethodEnumValues)
+}
+
+// Implement encoding.TextMarshaler so learning methods are written by name in configuration files.
+func (i LearningMethodEnum) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// Implement encoding.TextUnmarshaler so learning methods can be read by name from configuration files.
+func (i *LearningMethodEnum) UnmarshalText(text []byte) error {
+	parsedMethod, err := ParseLearningMethodEnum(string(text))
+	if err != nil {
+		return err
+	}
+	*i = parsedMethod
+	return nil
+}
+
 // Map an option from the LearningMethodEnum to the specific learning method.
 func getLearningMethod(learningMethod LearningMethodEnum) LearningMethod {
 	learningMethodMap := map[LearningMethodEnum]LearningMethod{
@@ -40,6 +67,7 @@ func fullSetLearningMethod(network *HopfieldNetwork, states []*mat.VecDense) []*
 	bar := progressbar.Default(int64(network.epochs), "LEARNING EPOCHS")
 	for epoch := 0; epoch < network.epochs; epoch++ {
 		network.learningRule(network, states)
+		network.emitLearnEpoch()
 		bar.Add(1)
 
 		tempLearnStateData := make([]*datacollector.LearnStateData, len(states))
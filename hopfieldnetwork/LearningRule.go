@@ -2,6 +2,7 @@ package hopfieldnetwork
 
 import (
 	"hmcalister/hopfield/hopfieldnetwork/domain"
+	"hmcalister/hopfield/hopfieldutils"
 	"math"
 
 	"gonum.org/v1/gonum/mat"
@@ -38,6 +39,36 @@ const (
 	BipolarMappedThermalDeltaLearningRule LearningRuleEnum = iota
 )
 
+// All valid LearningRuleEnum values, used when parsing learning rules from strings.
+var learningRuleEnumValues = []LearningRuleEnum{
+	HebbianLearningRule,
+	BipolarMappedHebbianLearningRule,
+	DeltaLearningRule,
+	BipolarMappedDeltaLearningRule,
+	ThermalDeltaLearningRule,
+	BipolarMappedThermalDeltaLearningRule,
+}
+
+// Parse a LearningRuleEnum from either its name (e.g. "DeltaLearningRule", case insensitive) or its integer value.
+func ParseLearningRuleEnum(name string) (LearningRuleEnum, error) {
+	return hopfieldutils.ParseEnum(name, learningRuleEnumValues)
+}
+
+// Implement encoding.TextMarshaler so learning rules are written by name in configuration files.
+func (i LearningRuleEnum) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// Implement encoding.TextUnmarshaler so learning rules can be read by name from configuration files.
+func (i *LearningRuleEnum) UnmarshalText(text []byte) error {
+	parsedRule, err := ParseLearningRuleEnum(string(text))
+	if err != nil {
+		return err
+	}
+	*i = parsedRule
+	return nil
+}
+
 // Map an option from the LearningRule enum to the specific learning rule
 //
 // # Arguments
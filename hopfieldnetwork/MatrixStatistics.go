@@ -0,0 +1,79 @@
+package hopfieldnetwork
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Summary statistics of a weight matrix.
+//
+// FrobeniusNorm is the Frobenius norm of the matrix.
+// MaximumWeight and MinimumWeight are the largest and smallest elements of the matrix.
+// RelativeAsymmetry is the Frobenius norm of W - W^T relative to the norm of W, 0 for a symmetric matrix.
+// MaximumDiagonalMagnitude is the largest absolute value on the diagonal, 0 for a zero-diagonal matrix.
+// TopEigenvalues are the largest eigenvalues of the symmetric part (W + W^T)/2 in descending order.
+// Only the symmetric part of the matrix contributes to the energy of a state, so these are real even for asymmetric matrices.
+type MatrixStatistics struct {
+	FrobeniusNorm            float64
+	MaximumWeight            float64
+	MinimumWeight            float64
+	RelativeAsymmetry        float64
+	MaximumDiagonalMagnitude float64
+	TopEigenvalues           []float64
+}
+
+// Compute the summary statistics of a square weight matrix.
+//
+// # Arguments
+//
+// matrix *mat.Dense: The (square) matrix to summarize
+//
+// numEigenvalues int: The number of eigenvalues to compute. Finding eigenvalues is expensive for large matrices,
+// so 0 skips the eigendecomposition entirely. Values larger than the matrix dimension find all eigenvalues.
+//
+// # Returns
+//
+// The statistics of the matrix
+func ComputeMatrixStatistics(matrix *mat.Dense, numEigenvalues int) *MatrixStatistics {
+	dimension, _ := matrix.Dims()
+	statistics := &MatrixStatistics{
+		FrobeniusNorm:  matrix.Norm(2),
+		MaximumWeight:  mat.Max(matrix),
+		MinimumWeight:  mat.Min(matrix),
+		TopEigenvalues: []float64{},
+	}
+
+	// Measure the asymmetry of the matrix as the relative norm of the antisymmetric part
+	difference := mat.NewDense(dimension, dimension, nil)
+	difference.Sub(matrix, matrix.T())
+	if statistics.FrobeniusNorm > 0 {
+		statistics.RelativeAsymmetry = difference.Norm(2) / statistics.FrobeniusNorm
+	}
+
+	for i := 0; i < dimension; i++ {
+		statistics.MaximumDiagonalMagnitude = math.Max(statistics.MaximumDiagonalMagnitude, math.Abs(matrix.At(i, i)))
+	}
+
+	if numEigenvalues <= 0 {
+		return statistics
+	}
+	if numEigenvalues > dimension {
+		numEigenvalues = dimension
+	}
+	symmetricPart := mat.NewSymDense(dimension, nil)
+	for i := 0; i < dimension; i++ {
+		for j := i; j < dimension; j++ {
+			symmetricPart.SetSym(i, j, 0.5*(matrix.At(i, j)+matrix.At(j, i)))
+		}
+	}
+	var eigenDecomposition mat.EigenSym
+	if eigenDecomposition.Factorize(symmetricPart, false) {
+		// Eigenvalues are found in ascending order
+		eigenvalues := eigenDecomposition.Values(nil)
+		for eigenvalueIndex := 0; eigenvalueIndex < numEigenvalues; eigenvalueIndex++ {
+			statistics.TopEigenvalues = append(statistics.TopEigenvalues, eigenvalues[len(eigenvalues)-1-eigenvalueIndex])
+		}
+	}
+	return statistics
+}
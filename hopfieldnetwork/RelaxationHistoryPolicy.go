@@ -0,0 +1,80 @@
+package hopfieldnetwork
+
+import "golang.org/x/exp/rand"
+
+// A policy selecting which steps of which relaxations are recorded as relaxation history, when intensive data collection is allowed.
+//
+// Recording every step of every probe state is very intensive, so the history can be reduced:
+//
+// StepInterval records only every StepInterval-th step. The initial and final steps are always recorded. Must be at least 1 (every step).
+//
+// SampleSize records only a random sample of this many probe states. If 0 all probe states are recorded (unless ProbeIndices are given).
+//
+// ProbeIndices records only the probe states with these indices, along with any sampled probe states.
+//
+// OverlapsOnly records only the overlap of each step with each target state, rather than the full state and energy profile.
+//
+// SpuriousOnly records only the probe states that relax to a spurious state, i.e. not a target state or the inverse of a target state.
+// Note the history of every selected probe state is still collected, but is discarded if the final state is not spurious.
+type RelaxationHistoryPolicy struct {
+	StepInterval int   `json:"stepInterval" yaml:"stepInterval"`
+	SampleSize   int   `json:"sampleSize" yaml:"sampleSize"`
+	ProbeIndices []int `json:"probeIndices" yaml:"probeIndices"`
+	OverlapsOnly bool  `json:"overlapsOnly" yaml:"overlapsOnly"`
+	SpuriousOnly bool  `json:"spuriousOnly" yaml:"spuriousOnly"`
+}
+
+// Get the default relaxation history policy, recording every step of every probe state in full.
+func DefaultRelaxationHistoryPolicy() RelaxationHistoryPolicy {
+	return RelaxationHistoryPolicy{
+		StepInterval: 1,
+		SampleSize:   0,
+		ProbeIndices: []int{},
+		OverlapsOnly: false,
+		SpuriousOnly: false,
+	}
+}
+
+// Determine if the step with the given index is recorded. Note the final step is always recorded, regardless of this method.
+func (policy RelaxationHistoryPolicy) recordsStep(stepIndex int) bool {
+	return stepIndex%policy.StepInterval == 0
+}
+
+// Select the probe states whose history is recorded.
+//
+// The random generator is only used if a random sample is requested, so the policy does not otherwise affect the random stream.
+//
+// # Arguments
+//
+// numProbes int: The number of probe states being relaxed
+//
+// randomGenerator *rand.Rand: The random generator used to select the sampled probe states
+//
+// # Returns
+//
+// A slice of flags, true for each probe state index that is recorded
+func (policy RelaxationHistoryPolicy) selectProbes(numProbes int, randomGenerator *rand.Rand) []bool {
+	recordedProbes := make([]bool, numProbes)
+	if policy.SampleSize == 0 && len(policy.ProbeIndices) == 0 {
+		for probeIndex := range recordedProbes {
+			recordedProbes[probeIndex] = true
+		}
+		return recordedProbes
+	}
+
+	for _, probeIndex := range policy.ProbeIndices {
+		if probeIndex < numProbes {
+			recordedProbes[probeIndex] = true
+		}
+	}
+	if policy.SampleSize > 0 {
+		sampleSize := policy.SampleSize
+		if sampleSize > numProbes {
+			sampleSize = numProbes
+		}
+		for _, probeIndex := range randomGenerator.Perm(numProbes)[:sampleSize] {
+			recordedProbes[probeIndex] = true
+		}
+	}
+	return recordedProbes
+}
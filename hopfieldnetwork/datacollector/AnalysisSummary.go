@@ -0,0 +1,24 @@
+package datacollector
+
+// Representation of the summary of the data of a trial, as computed by the analyze command
+// TargetStates is the number of target states probed (0 if the target state data is missing)
+// StableTargetStates is the number of target states that are stable
+// ProbeStates is the number of probe states relaxed
+// StableProbeStates is the number of probe states that relaxed to a stable state
+// ProbesRelaxedToTarget is the number of probe states that relaxed exactly onto a target state (or its inverse)
+// MeanRelaxationSteps is the mean number of steps taken to relax each probe state
+// UniqueRelaxedStates is the number of unique relaxed states (0 if the unique state data is missing)
+type AnalysisSummaryData struct {
+	TargetStates          int     `parquet:"name=TargetStates, type=INT32"`
+	StableTargetStates    int     `parquet:"name=StableTargetStates, type=INT32"`
+	ProbeStates           int     `parquet:"name=ProbeStates, type=INT32"`
+	StableProbeStates     int     `parquet:"name=StableProbeStates, type=INT32"`
+	ProbesRelaxedToTarget int     `parquet:"name=ProbesRelaxedToTarget, type=INT32"`
+	MeanRelaxationSteps   float64 `parquet:"name=MeanRelaxationSteps, type=DOUBLE"`
+	UniqueRelaxedStates   int     `parquet:"name=UniqueRelaxedStates, type=INT32"`
+}
+
+// Write the AnalysisSummary struct to the specified data file, in a parquet format
+func WriteAnalysisSummary(dataFile string, summaryData *AnalysisSummaryData) error {
+	return writeDataRows(dataFile, DataFileSettings{}, []*AnalysisSummaryData{summaryData})
+}
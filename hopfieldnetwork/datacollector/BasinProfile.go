@@ -0,0 +1,44 @@
+package datacollector
+
+// Representation of the recall of a target state from probes at a single Hamming distance, as computed by the basin command
+// TargetIndex is the index of the target state the probes were generated from
+// Distance is the Hamming distance (number of flipped units) of each probe from the target state
+// Probes is the number of probes generated at this distance
+// StableProbes is the number of probes that relaxed to a stable state
+// CorrectRecalls is the number of probes that relaxed exactly onto the target state
+// InverseRecalls is the number of probes that relaxed exactly onto the inverse of the target state
+// RecallFraction is the fraction of probes that relaxed exactly onto the target state
+// MeanRelaxationSteps is the mean number of steps taken to relax each probe
+type BasinProfileData struct {
+	TargetIndex         int     `parquet:"name=TargetIndex, type=INT32"`
+	Distance            int     `parquet:"name=Distance, type=INT32"`
+	Probes              int     `parquet:"name=Probes, type=INT32"`
+	StableProbes        int     `parquet:"name=StableProbes, type=INT32"`
+	CorrectRecalls      int     `parquet:"name=CorrectRecalls, type=INT32"`
+	InverseRecalls      int     `parquet:"name=InverseRecalls, type=INT32"`
+	RecallFraction      float64 `parquet:"name=RecallFraction, type=DOUBLE"`
+	MeanRelaxationSteps float64 `parquet:"name=MeanRelaxationSteps, type=DOUBLE"`
+}
+
+// Representation of the estimated basin of attraction of a single target state, as computed by the basin command
+// TargetIndex is the index of the target state
+// BasinRadius is the largest Hamming distance such that the recall fraction at that distance (and every smaller distance probed)
+// is at least the recall threshold, or -1 if even the target state itself is not recalled
+// RecallThreshold is the fraction of correct recalls required at each distance
+// MaximumDistance is the largest Hamming distance probed, so a BasinRadius equal to MaximumDistance is only a lower bound
+type BasinRadiusData struct {
+	TargetIndex     int     `parquet:"name=TargetIndex, type=INT32"`
+	BasinRadius     int     `parquet:"name=BasinRadius, type=INT32"`
+	RecallThreshold float64 `parquet:"name=RecallThreshold, type=DOUBLE"`
+	MaximumDistance int     `parquet:"name=MaximumDistance, type=INT32"`
+}
+
+// Write the basin profiles of all target states to the specified data file, in the given output format
+func WriteBasinProfiles(dataFile string, settings DataFileSettings, profileData []*BasinProfileData) error {
+	return writeDataRows(dataFile, settings, profileData)
+}
+
+// Write the basin radii of all target states to the specified data file, in the given output format
+func WriteBasinRadii(dataFile string, settings DataFileSettings, radiusData []*BasinRadiusData) error {
+	return writeDataRows(dataFile, settings, radiusData)
+}
@@ -0,0 +1,58 @@
+package datacollector
+
+// Representation of a single bisection search for the storage capacity of a network, as computed by the capacity command
+// SearchIndex is the index of the search within the capacity estimate
+// Repeat is the repeat index of this search for this configuration
+// Seed is the seed used for every network of the search
+// NetworkDimension is the dimension of the network
+// LearningRule is the network learning rule (as a string)
+// LearningNoiseScale is the scale of the noise applied during learning
+// CriticalTargetStates is the largest number of target states that were learned stably, or 0 if even the minimum failed
+// Alpha is the critical load, CriticalTargetStates divided by NetworkDimension
+// Evaluations is the number of networks trained during the search
+// ReachedMaximum is true if the maximum number of target states searched was learned stably, so Alpha is only a lower bound
+type CapacitySearchData struct {
+	SearchIndex          int     `parquet:"name=SearchIndex, type=INT32"`
+	Repeat               int     `parquet:"name=Repeat, type=INT32"`
+	Seed                 int64   `parquet:"name=Seed, type=INT64"`
+	NetworkDimension     int     `parquet:"name=NetworkDimension, type=INT32"`
+	LearningRule         string  `parquet:"name=LearningRule, type=BYTE_ARRAY, convertedtype=UTF8"`
+	LearningNoiseScale   float64 `parquet:"name=LearningNoiseScale, type=DOUBLE"`
+	CriticalTargetStates int     `parquet:"name=CriticalTargetStates, type=INT32"`
+	Alpha                float64 `parquet:"name=Alpha, type=DOUBLE"`
+	Evaluations          int     `parquet:"name=Evaluations, type=INT32"`
+	ReachedMaximum       bool    `parquet:"name=ReachedMaximum, type=BOOLEAN"`
+}
+
+// Representation of the storage capacity estimate of a single configuration, aggregated over the repeated searches
+// NetworkDimension is the dimension of the network
+// LearningRule is the network learning rule (as a string)
+// LearningNoiseScale is the scale of the noise applied during learning
+// Searches is the number of searches (repeats) aggregated
+// MeanCriticalTargetStates is the mean of the critical number of target states over the searches
+// MeanAlpha is the mean critical load over the searches
+// AlphaStandardDeviation is the sample standard deviation of the critical load, 0 for a single search
+// AlphaLowerBound and AlphaUpperBound are the confidence interval of the mean critical load, using the Student's t distribution
+// ConfidenceLevel is the confidence level of the interval
+type CapacityEstimateData struct {
+	NetworkDimension         int     `parquet:"name=NetworkDimension, type=INT32"`
+	LearningRule             string  `parquet:"name=LearningRule, type=BYTE_ARRAY, convertedtype=UTF8"`
+	LearningNoiseScale       float64 `parquet:"name=LearningNoiseScale, type=DOUBLE"`
+	Searches                 int     `parquet:"name=Searches, type=INT32"`
+	MeanCriticalTargetStates float64 `parquet:"name=MeanCriticalTargetStates, type=DOUBLE"`
+	MeanAlpha                float64 `parquet:"name=MeanAlpha, type=DOUBLE"`
+	AlphaStandardDeviation   float64 `parquet:"name=AlphaStandardDeviation, type=DOUBLE"`
+	AlphaLowerBound          float64 `parquet:"name=AlphaLowerBound, type=DOUBLE"`
+	AlphaUpperBound          float64 `parquet:"name=AlphaUpperBound, type=DOUBLE"`
+	ConfidenceLevel          float64 `parquet:"name=ConfidenceLevel, type=DOUBLE"`
+}
+
+// Write the searches of a capacity estimate to the specified data file, in the given output format
+func WriteCapacitySearches(dataFile string, settings DataFileSettings, searchData []*CapacitySearchData) error {
+	return writeDataRows(dataFile, settings, searchData)
+}
+
+// Write the capacity estimates of all configurations to the specified data file, in the given output format
+func WriteCapacityEstimates(dataFile string, settings DataFileSettings, estimateData []*CapacityEstimateData) error {
+	return writeDataRows(dataFile, settings, estimateData)
+}
@@ -1,83 +1,212 @@
 package datacollector
 
 import (
-	"hmcalister/hopfield/hopfieldutils"
+	"errors"
+	"fmt"
+	"sync"
 )
 
 // ------------------------------------------------------------------------------------------------
 // DATA COLLECTION EVENT ENUM
 // ------------------------------------------------------------------------------------------------
 
+// An enum to note the type of a data collection event.
+type DataCollectionEventEnum int
+
 const (
-	DataCollectionEvent_RelaxationResult  = iota
-	DataCollectionEvent_RelaxationHistory = iota
-	DataCollectionEvent_TargetStateProbe  = iota
-	DataCollectionEvent_LearnState        = iota
+	DataCollectionEvent_RelaxationResult  DataCollectionEventEnum = iota
+	DataCollectionEvent_RelaxationHistory DataCollectionEventEnum = iota
+	DataCollectionEvent_TargetStateProbe  DataCollectionEventEnum = iota
+	DataCollectionEvent_LearnState        DataCollectionEventEnum = iota
+	DataCollectionEvent_LearnEpoch        DataCollectionEventEnum = iota
+	DataCollectionEvent_MatrixSnapshot    DataCollectionEventEnum = iota
 )
 
+// An Event is the data of a single data collection event, such as a *RelaxationResultData.
+//
+// The interface is sealed, so only the data structs of this package are events. Each event type is
+// implemented by exactly one (pointer to a) data struct, so handlers may rely on the concrete type of an event.
+type Event interface {
+	// Get the type of this event
+	EventType() DataCollectionEventEnum
+
+	// Seal the interface to this package
+	isEvent()
+}
+
 // ------------------------------------------------------------------------------------------------
 // DATA COLLECTOR STRUCT AND BASE METHODS
 // ------------------------------------------------------------------------------------------------
 
+// A DataCollector sends events to the handlers listening to each event type, from a single goroutine.
+//
+// The lifecycle of a collector is:
+//   - Create the collector (NewDataCollector or NewBufferedDataCollector) and add handlers (AddHandler)
+//   - Start data collection (Start)
+//   - Emit events, possibly from many goroutines (Emit)
+//   - Close the collector (Close), which handles all pending events before closing the handlers
 type DataCollector struct {
-	handlers     []*dataHandler
-	EventChannel chan hopfieldutils.IndexedWrapper[interface{}]
-}
-
-// Start data collection by spinning up a goroutine that forever looks at EventChannel,
-// taking any incoming events and sending them to the listening handlers.
-func (collector *DataCollector) CollectData() {
-	if len(collector.handlers) == 0 {
-		for {
-			<-collector.EventChannel
-		}
-	}
-
-	for {
-		event := <-collector.EventChannel
-
-		for _, handler := range collector.handlers {
-			if handler.getEventID() == event.Index {
-				handler.handleEvent(handler.dataWriter, event.Data)
-			}
-		}
-	}
+	handlers       []DataHandler
+	eventHandlers  map[DataCollectionEventEnum][]DataHandler
+	failedHandlers map[DataHandler]bool
+	errs           []error
+	errsMutex      sync.Mutex
+	eventChannel   chan Event
+	collectionDone chan struct{}
+	// Guards started and closed, so events are never sent after the event channel is closed
+	stateMutex sync.RWMutex
+	started    bool
+	closed     bool
 }
 
-// Create a new data writer.
+// Create a new data collector, with an unbuffered event channel.
 //
-// Note that by default the data writer will collect nothing, not responding to any callbacks.
-// To add a data collection event, call one of the Add* methods on the resulting DataCollector object.
+// Note that by default the data collector will collect nothing, not responding to any callbacks.
+// To add a data collection event, call AddHandler on the resulting DataCollector object.
 // This will make that callback trigger a collection event.
 func NewDataCollector() *DataCollector {
+	return NewBufferedDataCollector(0)
+}
+
+// Create a new data collector, with an event channel buffering up to bufferSize events.
+//
+// A buffer allows emitters to continue while the handlers are busy (e.g. writing to disk), at the cost of memory.
+// See NewDataCollector.
+func NewBufferedDataCollector(bufferSize int) *DataCollector {
 	return &DataCollector{
-		handlers:     make([]*dataHandler, 0),
-		EventChannel: make(chan hopfieldutils.IndexedWrapper[interface{}]),
+		handlers:       make([]DataHandler, 0),
+		eventHandlers:  make(map[DataCollectionEventEnum][]DataHandler),
+		failedHandlers: make(map[DataHandler]bool),
+		eventChannel:   make(chan Event, bufferSize),
+		collectionDone: make(chan struct{}),
 	}
 }
 
-// Add a state relaxed event handler.
+// Add an event handler. This may be one of the handlers of this package, or any other DataHandler.
+//
+// Handlers must be added before data collection starts.
 //
 // # Arguments
 //
-// dataHandler *dataHandler: The handler to add to the collector
+// dataHandler DataHandler: The handler to add to the collector
 //
 // # Returns
 //
 // A pointer to the DataCollector, to allow for chaining of AddHandler calls
-func (collector *DataCollector) AddHandler(dataHandler *dataHandler) *DataCollector {
+func (collector *DataCollector) AddHandler(dataHandler DataHandler) *DataCollector {
 	collector.handlers = append(collector.handlers, dataHandler)
+	for _, eventType := range dataHandler.EventTypes() {
+		collector.eventHandlers[eventType] = append(collector.eventHandlers[eventType], dataHandler)
+	}
 	return collector
 }
 
-// Call WriteStop on all parquet writers in the handlers. This means data should be written nicely to disk.
+// Start data collection by spinning up a goroutine that takes incoming events and sends them to the listening handlers,
+// until the collector is closed. Calling Start more than once has no effect.
+//
+// Start must be called before events are emitted, as otherwise emitting may block.
+func (collector *DataCollector) Start() {
+	collector.stateMutex.Lock()
+	defer collector.stateMutex.Unlock()
+	if collector.started || collector.closed {
+		return
+	}
+	collector.started = true
+	go collector.collectData()
+}
+
+// Send each event from the event channel to the listening handlers, until the event channel is closed and drained.
+func (collector *DataCollector) collectData() {
+	defer close(collector.collectionDone)
+	for event := range collector.eventChannel {
+		for _, handler := range collector.eventHandlers[event.EventType()] {
+			if collector.failedHandlers[handler] {
+				continue
+			}
+			if err := handler.Handle(event); err != nil {
+				collector.failedHandlers[handler] = true
+				collector.addErr(err)
+			}
+		}
+	}
+}
+
+// Determine if any handler receives events of the given type.
+//
+// Emitters may check this before creating expensive events, and events no handler receives should not be sent at all.
+func (collector *DataCollector) IsSubscribed(eventType DataCollectionEventEnum) bool {
+	return len(collector.eventHandlers[eventType]) > 0
+}
+
+// Send an event to the handlers of that event type. This is safe to call from many goroutines.
+//
+// If no handler receives events of this type the event is dropped immediately, so emitting is
+// safe (and cheap) even if data collection has not started. Emitting an event after the collector
+// is closed is an error, reported by Err.
+//
+// # Arguments
+//
+// event Event: The data of the event, e.g. a *RelaxationResultData
+func (collector *DataCollector) Emit(event Event) {
+	if !collector.IsSubscribed(event.EventType()) {
+		return
+	}
+
+	collector.stateMutex.RLock()
+	defer collector.stateMutex.RUnlock()
+	if collector.closed {
+		collector.addErr(fmt.Errorf("%v event emitted after the data collector was closed", event.EventType()))
+		return
+	}
+	collector.eventChannel <- event
+}
+
+// Record an error of a handler, to be reported by Err and Close.
+func (collector *DataCollector) addErr(err error) {
+	collector.errsMutex.Lock()
+	defer collector.errsMutex.Unlock()
+	collector.errs = append(collector.errs, err)
+}
+
+// Get the errors of the handlers so far (e.g. failed writes), or nil if all events have been handled successfully.
 //
-// Consider calling `defer collector.WriteStop()`
-func (collector *DataCollector) WriteStop() error {
+// This may be called at any time, for example to abort a long experiment early if data can not be written.
+// Each handler reports at most one error from handling events, as a failed handler is given no further events.
+func (collector *DataCollector) Err() error {
+	collector.errsMutex.Lock()
+	defer collector.errsMutex.Unlock()
+	return errors.Join(collector.errs...)
+}
+
+// Close the collector, handling all pending events before closing all handlers. This means data should be written nicely to disk.
+//
+// Close waits for the collection goroutine to finish, so no event emitted before Close is lost. All handlers are closed,
+// even if some fail. The returned error joins any errors from handling events (see Err) with any errors from closing the handlers.
+// Calling Close more than once only reports the errors again.
+//
+// Consider calling `defer collector.Close()`
+func (collector *DataCollector) Close() error {
+	collector.stateMutex.Lock()
+	if collector.closed {
+		collector.stateMutex.Unlock()
+		return collector.Err()
+	}
+	collector.closed = true
+	started := collector.started
+	close(collector.eventChannel)
+	collector.stateMutex.Unlock()
+
+	if started {
+		<-collector.collectionDone
+	} else {
+		// Handle any buffered events, in case events were emitted without starting the collector
+		collector.collectData()
+	}
+
 	for _, handler := range collector.handlers {
-		if err := handler.writeStop(); err != nil {
-			return err
+		if err := handler.Close(); err != nil {
+			collector.addErr(err)
 		}
 	}
-	return nil
+	return collector.Err()
 }
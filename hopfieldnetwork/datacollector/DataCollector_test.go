@@ -0,0 +1,93 @@
+package datacollector
+
+import (
+	"path"
+	"sync"
+	"testing"
+)
+
+const (
+	testEmitters         = 16
+	testEventsPerEmitter = 5000
+)
+
+// Emit events from many goroutines at once, then close the collector.
+func emitUnderLoad(collector *DataCollector) {
+	var emitterGroup sync.WaitGroup
+	for emitterIndex := 0; emitterIndex < testEmitters; emitterIndex++ {
+		emitterGroup.Add(1)
+		go func(emitterIndex int) {
+			defer emitterGroup.Done()
+			for eventIndex := 0; eventIndex < testEventsPerEmitter; eventIndex++ {
+				collector.Emit(&RelaxationResultData{
+					StateIndex: emitterIndex*testEventsPerEmitter + eventIndex,
+					Stable:     true,
+					NumSteps:   1,
+					FinalState: []float64{1, -1},
+				})
+			}
+		}(emitterIndex)
+	}
+	emitterGroup.Wait()
+}
+
+// Test that every event emitted before Close is handled, for both unbuffered and buffered collectors.
+func TestCloseDrainsAllEvents(t *testing.T) {
+	for _, bufferSize := range []int{0, 1, 1024} {
+		collector := NewBufferedDataCollector(bufferSize)
+		seen := make(map[int]bool)
+		collector.AddHandler(NewFuncHandler(func(result *RelaxationResultData) {
+			seen[result.StateIndex] = true
+		}))
+		collector.Start()
+
+		emitUnderLoad(collector)
+		if err := collector.Close(); err != nil {
+			t.Fatalf("buffer size %v: unexpected error: %v", bufferSize, err)
+		}
+
+		if len(seen) != testEmitters*testEventsPerEmitter {
+			t.Errorf("buffer size %v: handled %v unique events, expected %v", bufferSize, len(seen), testEmitters*testEventsPerEmitter)
+		}
+	}
+}
+
+// Test that every event emitted before Close is written to a parquet data file.
+func TestCloseWritesAllEvents(t *testing.T) {
+	dataFile := path.Join(t.TempDir(), "relaxationResult.pq")
+	handler, err := NewRelaxationResultHandler(dataFile, DataFileSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector := NewBufferedDataCollector(64)
+	collector.AddHandler(handler)
+	collector.Start()
+
+	emitUnderLoad(collector)
+	if err := collector.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows, err := ReadParquetData[RelaxationResultData](dataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != testEmitters*testEventsPerEmitter {
+		t.Errorf("data file has %v rows, expected %v", len(rows), testEmitters*testEventsPerEmitter)
+	}
+}
+
+// Test that an event emitted after Close is reported as an error, rather than panicking or being silently dropped.
+func TestEmitAfterClose(t *testing.T) {
+	collector := NewDataCollector()
+	collector.AddHandler(NewFuncHandler(func(*LearnStateData) {}))
+	collector.Start()
+	if err := collector.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	collector.Emit(&LearnStateData{})
+	if collector.Err() == nil {
+		t.Error("expected an error for an event emitted after close")
+	}
+}
@@ -0,0 +1,390 @@
+package datacollector
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/xitongsys/parquet-go/source"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// The separator between the elements of a repeated field in a CSV data file
+const CSV_REPEATED_FIELD_SEPARATOR = ";"
+
+// The strings written in place of non-finite floats in a JSON Lines data file, as JSON numbers cannot be NaN or infinite
+const (
+	JSON_NAN               = "NaN"
+	JSON_POSITIVE_INFINITY = "Infinity"
+	JSON_NEGATIVE_INFINITY = "-Infinity"
+)
+
+// A dataFileWriter writes rows (pointers to a data struct) to a data file in one of the output formats.
+//
+// Only one type of data struct may be written to each dataFileWriter.
+type dataFileWriter interface {
+	// Write a single row, a pointer to the data struct the writer was created with.
+	Write(row interface{}) error
+
+	// Finish writing, flushing any buffered rows and closing the data file.
+	Close() error
+}
+
+// Create a new dataFileWriter to a given file path, for the given data struct and output format.
+//
+// # Arguments
+//
+// dataFilePath string: The path to the data file required
+//
+// settings DataFileSettings: The format (and parquet settings) to write the data file with
+//
+// dataStruct (pointer to a struct): A valid struct for writing in the parquet format.
+// Should be called with `new(dataStruct)` as argument. The other formats use the column names of the parquet tags.
+//
+// # Returns
+//
+// (dataFileWriter, nil) on success, or (nil, error) if the file could not be created.
+func newDataFileWriter(dataFilePath string, settings DataFileSettings, dataStruct interface{}) (dataFileWriter, error) {
+	switch settings.OutputFormat {
+	case ParquetFormat:
+		fileHandle, parquetWriter, err := newParquetWriter(dataFilePath, dataStruct, settings.Parquet)
+		if err != nil {
+			return nil, err
+		}
+		return &parquetFileWriter{
+			fileHandle:    fileHandle,
+			parquetWriter: parquetWriter,
+		}, nil
+	case CSVFormat:
+		return newCSVFileWriter(dataFilePath, dataStruct)
+	case JSONLinesFormat:
+		return newJSONLinesFileWriter(dataFilePath, dataStruct)
+	default:
+		return nil, fmt.Errorf("%v: unknown output format %v", dataFilePath, settings.OutputFormat)
+	}
+}
+
+// A dataFileWriter to a parquet file.
+type parquetFileWriter struct {
+	fileHandle    source.ParquetFile
+	parquetWriter *writer.ParquetWriter
+}
+
+func (dataWriter *parquetFileWriter) Write(row interface{}) error {
+	return dataWriter.parquetWriter.Write(row)
+}
+
+func (dataWriter *parquetFileWriter) Close() error {
+	if err := dataWriter.parquetWriter.WriteStop(); err != nil {
+		dataWriter.fileHandle.Close()
+		return err
+	}
+	return dataWriter.fileHandle.Close()
+}
+
+// A column of a data struct, as written to CSV and JSON Lines data files.
+type dataColumn struct {
+	name       string
+	fieldIndex int
+}
+
+// Get the columns of a data struct, named as in the parquet tags of the struct.
+//
+// Fields without a parquet tag are not columns.
+func dataColumnsOf(dataStruct interface{}) []dataColumn {
+	structType := reflect.TypeOf(dataStruct)
+	for structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+
+	columns := []dataColumn{}
+	for fieldIndex := 0; fieldIndex < structType.NumField(); fieldIndex++ {
+		field := structType.Field(fieldIndex)
+		tag, ok := field.Tag.Lookup("parquet")
+		if !ok {
+			continue
+		}
+		column := dataColumn{
+			name:       field.Name,
+			fieldIndex: fieldIndex,
+		}
+		for _, tagItem := range strings.Split(tag, ",") {
+			key, value, found := strings.Cut(strings.TrimSpace(tagItem), "=")
+			if found && strings.ToLower(key) == "name" {
+				column.name = value
+			}
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// Get the value of each column of a row, where the row is a pointer to a data struct.
+func columnValues(columns []dataColumn, row interface{}) []reflect.Value {
+	rowValue := reflect.Indirect(reflect.ValueOf(row))
+	values := make([]reflect.Value, len(columns))
+	for columnIndex, column := range columns {
+		values[columnIndex] = rowValue.Field(column.fieldIndex)
+	}
+	return values
+}
+
+// A dataFileWriter to a CSV file, with a header row of the column names.
+type csvFileWriter struct {
+	fileHandle *os.File
+	csvWriter  *csv.Writer
+	columns    []dataColumn
+	record     []string
+}
+
+func newCSVFileWriter(dataFilePath string, dataStruct interface{}) (*csvFileWriter, error) {
+	fileHandle, err := os.Create(dataFilePath)
+	if err != nil {
+		// File system errors already include the data file path
+		return nil, err
+	}
+	dataWriter := &csvFileWriter{
+		fileHandle: fileHandle,
+		csvWriter:  csv.NewWriter(fileHandle),
+		columns:    dataColumnsOf(dataStruct),
+	}
+	dataWriter.record = make([]string, len(dataWriter.columns))
+
+	for columnIndex, column := range dataWriter.columns {
+		dataWriter.record[columnIndex] = column.name
+	}
+	if err := dataWriter.csvWriter.Write(dataWriter.record); err != nil {
+		fileHandle.Close()
+		return nil, fmt.Errorf("%v: %w", dataFilePath, err)
+	}
+	return dataWriter, nil
+}
+
+// Format a single value as a CSV field. Repeated fields (slices) have their elements joined by CSV_REPEATED_FIELD_SEPARATOR.
+func formatCSVField(value reflect.Value) (string, error) {
+	switch value.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'g', -1, 64), nil
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Slice:
+		elements := make([]string, value.Len())
+		for elementIndex := range elements {
+			element, err := formatCSVField(value.Index(elementIndex))
+			if err != nil {
+				return "", err
+			}
+			elements[elementIndex] = element
+		}
+		return strings.Join(elements, CSV_REPEATED_FIELD_SEPARATOR), nil
+	default:
+		return "", fmt.Errorf("cannot write field of type %v to CSV", value.Type())
+	}
+}
+
+func (dataWriter *csvFileWriter) Write(row interface{}) error {
+	var err error
+	for columnIndex, value := range columnValues(dataWriter.columns, row) {
+		dataWriter.record[columnIndex], err = formatCSVField(value)
+		if err != nil {
+			return fmt.Errorf("column %v: %w", dataWriter.columns[columnIndex].name, err)
+		}
+	}
+	return dataWriter.csvWriter.Write(dataWriter.record)
+}
+
+func (dataWriter *csvFileWriter) Close() error {
+	dataWriter.csvWriter.Flush()
+	if err := dataWriter.csvWriter.Error(); err != nil {
+		dataWriter.fileHandle.Close()
+		return err
+	}
+	return dataWriter.fileHandle.Close()
+}
+
+// A dataFileWriter to a JSON Lines file, writing each row as a JSON object with keys in column order.
+type jsonLinesFileWriter struct {
+	fileHandle   *os.File
+	bufferWriter *bufio.Writer
+	columns      []dataColumn
+	// The JSON encoded column names, including the separator before each name
+	columnKeys [][]byte
+}
+
+func newJSONLinesFileWriter(dataFilePath string, dataStruct interface{}) (*jsonLinesFileWriter, error) {
+	fileHandle, err := os.Create(dataFilePath)
+	if err != nil {
+		// File system errors already include the data file path
+		return nil, err
+	}
+	dataWriter := &jsonLinesFileWriter{
+		fileHandle:   fileHandle,
+		bufferWriter: bufio.NewWriter(fileHandle),
+		columns:      dataColumnsOf(dataStruct),
+	}
+
+	dataWriter.columnKeys = make([][]byte, len(dataWriter.columns))
+	for columnIndex, column := range dataWriter.columns {
+		encodedName, err := json.Marshal(column.name)
+		if err != nil {
+			fileHandle.Close()
+			return nil, fmt.Errorf("%v: %w", dataFilePath, err)
+		}
+		separator := ","
+		if columnIndex == 0 {
+			separator = "{"
+		}
+		dataWriter.columnKeys[columnIndex] = append([]byte(separator), append(encodedName, ':')...)
+	}
+	return dataWriter, nil
+}
+
+// Append a single value to a JSON Lines row. Repeated fields (slices) are written as arrays, with empty repeated fields
+// written as empty arrays rather than null.
+//
+// JSON has no representation of non-finite numbers, so NaN and infinite floats are written as the strings
+// JSON_NAN, JSON_POSITIVE_INFINITY and JSON_NEGATIVE_INFINITY.
+func appendJSONField(line []byte, value reflect.Value) ([]byte, error) {
+	switch value.Kind() {
+	case reflect.Float32, reflect.Float64:
+		floatValue := value.Float()
+		switch {
+		case math.IsNaN(floatValue):
+			return strconv.AppendQuote(line, JSON_NAN), nil
+		case math.IsInf(floatValue, 1):
+			return strconv.AppendQuote(line, JSON_POSITIVE_INFINITY), nil
+		case math.IsInf(floatValue, -1):
+			return strconv.AppendQuote(line, JSON_NEGATIVE_INFINITY), nil
+		}
+	case reflect.Slice:
+		line = append(line, '[')
+		for elementIndex := 0; elementIndex < value.Len(); elementIndex++ {
+			if elementIndex > 0 {
+				line = append(line, ',')
+			}
+			var err error
+			line, err = appendJSONField(line, value.Index(elementIndex))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return append(line, ']'), nil
+	}
+
+	encodedValue, err := json.Marshal(value.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return append(line, encodedValue...), nil
+}
+
+func (dataWriter *jsonLinesFileWriter) Write(row interface{}) error {
+	// Encode the entire row before writing, so a failed row does not leave a partial line in the file
+	line := []byte{}
+	for columnIndex, value := range columnValues(dataWriter.columns, row) {
+		line = append(line, dataWriter.columnKeys[columnIndex]...)
+		var err error
+		line, err = appendJSONField(line, value)
+		if err != nil {
+			return fmt.Errorf("column %v: %w", dataWriter.columns[columnIndex].name, err)
+		}
+	}
+	if len(dataWriter.columns) == 0 {
+		line = append(line, '{')
+	}
+	line = append(line, "}\n"...)
+
+	_, err := dataWriter.bufferWriter.Write(line)
+	return err
+}
+
+func (dataWriter *jsonLinesFileWriter) Close() error {
+	if err := dataWriter.bufferWriter.Flush(); err != nil {
+		dataWriter.fileHandle.Close()
+		return err
+	}
+	return dataWriter.fileHandle.Close()
+}
+
+// Count the rows of a data file written in the given output format.
+//
+// Parquet files record the number of rows in their metadata. CSV files are read in full, not counting the header row,
+// and JSON Lines files are scanned for the end of each line.
+//
+// # Arguments
+//
+// dataFilePath string: The path to the data file
+//
+// outputFormat OutputFormatEnum: The format the data file was written in
+//
+// # Returns
+//
+// (number of rows, nil) on success, or (0, error) if the file could not be read
+func CountDataFileRows(dataFilePath string, outputFormat OutputFormatEnum) (int64, error) {
+	if outputFormat == ParquetFormat {
+		metadata, err := ReadParquetMetadata(dataFilePath)
+		if err != nil {
+			return 0, err
+		}
+		return metadata.Rows, nil
+	}
+
+	fileHandle, err := os.Open(dataFilePath)
+	if err != nil {
+		return 0, err
+	}
+	defer fileHandle.Close()
+
+	var rows int64
+	switch outputFormat {
+	case CSVFormat:
+		// Count records rather than lines, as quoted fields may span lines
+		csvReader := csv.NewReader(bufio.NewReader(fileHandle))
+		csvReader.ReuseRecord = true
+		for {
+			_, err := csvReader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return 0, fmt.Errorf("%v: %w", dataFilePath, err)
+			}
+			rows += 1
+		}
+		// The header row is not a row of data
+		if rows > 0 {
+			rows -= 1
+		}
+	case JSONLinesFormat:
+		// Each row is a single line, as JSON escapes any newline within a string
+		buffer := make([]byte, 64*1024)
+		for {
+			bytesRead, err := fileHandle.Read(buffer)
+			rows += int64(bytes.Count(buffer[:bytesRead], []byte{'\n'}))
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+	default:
+		return 0, fmt.Errorf("%v: unknown output format %v", dataFilePath, outputFormat)
+	}
+	return rows, nil
+}
@@ -0,0 +1,70 @@
+package datacollector
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+// Test that the rows of a data file are counted in every output format, not counting the CSV header row.
+func TestCountDataFileRows(t *testing.T) {
+	rows := []*LearnStateData{
+		{Epoch: 0, EnergyProfile: []float64{-1, -2}, Stable: true},
+		{Epoch: 1, EnergyProfile: []float64{}, Stable: false},
+		{Epoch: 2, EnergyProfile: []float64{0.5}, Stable: true},
+	}
+	for _, outputFormat := range []OutputFormatEnum{ParquetFormat, CSVFormat, JSONLinesFormat} {
+		dataFile := path.Join(t.TempDir(), "learnStateData"+outputFormat.FileExtension())
+		if err := writeDataRows(dataFile, DataFileSettings{OutputFormat: outputFormat}, rows); err != nil {
+			t.Fatalf("%v: %v", outputFormat, err)
+		}
+		numRows, err := CountDataFileRows(dataFile, outputFormat)
+		if err != nil {
+			t.Fatalf("%v: %v", outputFormat, err)
+		}
+		if numRows != int64(len(rows)) {
+			t.Errorf("%v: counted %v rows, expected %v", outputFormat, numRows, len(rows))
+		}
+	}
+}
+
+// Test that non-finite floats are written to JSON Lines data files as strings, keeping every line valid JSON.
+func TestJSONLinesNonFiniteFloats(t *testing.T) {
+	type nonFiniteData struct {
+		Value   float64   `parquet:"name=Value, type=DOUBLE"`
+		Profile []float64 `parquet:"name=Profile, type=DOUBLE, repetitiontype=REPEATED"`
+		Empty   []float64 `parquet:"name=Empty, type=DOUBLE, repetitiontype=REPEATED"`
+	}
+	dataFile := path.Join(t.TempDir(), "nonFinite.jsonl")
+	rows := []*nonFiniteData{
+		{Value: math.NaN(), Profile: []float64{math.Inf(1), -0.5, math.Inf(-1)}},
+		{Value: 2.5, Profile: []float64{1}},
+	}
+	if err := writeDataRows(dataFile, DataFileSettings{OutputFormat: JSONLinesFormat}, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := os.ReadFile(dataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n")
+	if len(lines) != len(rows) {
+		t.Fatalf("read %v lines, expected %v", len(lines), len(rows))
+	}
+	expectedLines := []string{
+		`{"Value":"NaN","Profile":["Infinity",-0.5,"-Infinity"],"Empty":[]}`,
+		`{"Value":2.5,"Profile":[1],"Empty":[]}`,
+	}
+	for lineIndex, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Errorf("line %v is not valid JSON: %v", lineIndex, line)
+		}
+		if line != expectedLines[lineIndex] {
+			t.Errorf("line %v written as %v, expected %v", lineIndex, line, expectedLines[lineIndex])
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package datacollector
+
+// A DataHandler receives the events of a DataCollector, for example to write those events to a data file.
+//
+// The handlers of this package (e.g. NewRelaxationResultHandler) write parquet files, but any type implementing
+// this interface may be added to a DataCollector to receive relaxation, learning, and probe events.
+type DataHandler interface {
+	// Get the event types this handler receives, e.g. DataCollectionEvent_RelaxationResult.
+	EventTypes() []DataCollectionEventEnum
+
+	// Handle a single event. Only events of the types returned by EventTypes are given to the handler,
+	// so the concrete type of the event is known (e.g. *RelaxationResultData for DataCollectionEvent_RelaxationResult).
+	//
+	// Events are given from a single goroutine, so handlers need not be safe for concurrent use.
+	// An error (e.g. a failed write) is reported by the DataCollector, see DataCollector.Err.
+	// Once a handler has returned an error it is given no further events, but is still closed.
+	Handle(event Event) error
+
+	// Close the handler once all events are handled, writing any remaining data.
+	Close() error
+}
+
+// A DataHandler calling a function for each event of a single type.
+type funcHandler[T Event] struct {
+	handleFn func(T)
+}
+
+// Create a DataHandler that calls a function for each event of one type, selected by the argument type of that function.
+//
+// For example, `NewFuncHandler(func(result *RelaxationResultData) {...})` receives every relaxation result event.
+//
+// # Arguments
+//
+// handleFn func(T): The function to call with each event
+//
+// # Returns
+//
+// A DataHandler that can be added to a DataCollector. Closing this handler does nothing.
+func NewFuncHandler[T Event](handleFn func(event T)) DataHandler {
+	return &funcHandler[T]{
+		handleFn: handleFn,
+	}
+}
+
+func (handler *funcHandler[T]) EventTypes() []DataCollectionEventEnum {
+	return []DataCollectionEventEnum{eventTypeOf[T]()}
+}
+
+func (handler *funcHandler[T]) Handle(event Event) error {
+	handler.handleFn(event.(T))
+	return nil
+}
+
+func (handler *funcHandler[T]) Close() error {
+	return nil
+}
+
+// Get the event type of the events with concrete type T.
+//
+// Each event type is implemented by a pointer to a data struct, whose EventType method does not use the receiver,
+// so the event type is found from a nil pointer.
+func eventTypeOf[T Event]() DataCollectionEventEnum {
+	var event T
+	return event.EventType()
+}
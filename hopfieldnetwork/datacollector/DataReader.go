@@ -0,0 +1,76 @@
+package datacollector
+
+import "fmt"
+
+// Readers for the data files written by the handlers (and summary writers) of this package, in the parquet format.
+//
+// Each data file can be read entirely into memory (e.g. ReadRelaxationResults) or streamed one row at a time
+// (e.g. NewRelaxationResultIterator), which is preferred for large files such as relaxation histories.
+
+// Open a relaxation result data file (e.g. relaxationResult.pq) for iteration over its rows.
+func NewRelaxationResultIterator(dataFile string) (*ParquetIterator[RelaxationResultData], error) {
+	return NewParquetIterator[RelaxationResultData](dataFile)
+}
+
+// Read all rows of a relaxation result data file (e.g. relaxationResult.pq).
+func ReadRelaxationResults(dataFile string) ([]RelaxationResultData, error) {
+	return ReadParquetData[RelaxationResultData](dataFile)
+}
+
+// Open a relaxation history data file (e.g. relaxationHistory.pq) for iteration over its rows.
+func NewRelaxationHistoryIterator(dataFile string) (*ParquetIterator[RelaxationHistoryData], error) {
+	return NewParquetIterator[RelaxationHistoryData](dataFile)
+}
+
+// Read all rows of a relaxation history data file (e.g. relaxationHistory.pq).
+//
+// Relaxation histories can be very large, consider NewRelaxationHistoryIterator instead.
+func ReadRelaxationHistories(dataFile string) ([]RelaxationHistoryData, error) {
+	return ReadParquetData[RelaxationHistoryData](dataFile)
+}
+
+// Open a learn state data file (e.g. learnStateData.pq) for iteration over its rows.
+func NewLearnStateIterator(dataFile string) (*ParquetIterator[LearnStateData], error) {
+	return NewParquetIterator[LearnStateData](dataFile)
+}
+
+// Read all rows of a learn state data file (e.g. learnStateData.pq).
+func ReadLearnStates(dataFile string) ([]LearnStateData, error) {
+	return ReadParquetData[LearnStateData](dataFile)
+}
+
+// Open a target state probe data file (e.g. targetStateProbe.pq) for iteration over its rows.
+func NewTargetStateProbeIterator(dataFile string) (*ParquetIterator[TargetStateProbeData], error) {
+	return NewParquetIterator[TargetStateProbeData](dataFile)
+}
+
+// Read all rows of a target state probe data file (e.g. targetStateProbe.pq).
+func ReadTargetStateProbes(dataFile string) ([]TargetStateProbeData, error) {
+	return ReadParquetData[TargetStateProbeData](dataFile)
+}
+
+// Open a unique relaxed state data file (e.g. uniqueStates.pq) for iteration over its rows.
+func NewUniqueRelaxedStateIterator(dataFile string) (*ParquetIterator[UniqueRelaxedStateData], error) {
+	return NewParquetIterator[UniqueRelaxedStateData](dataFile)
+}
+
+// Read all rows of a unique relaxed state data file (e.g. uniqueStates.pq).
+func ReadUniqueRelaxedStates(dataFile string) ([]UniqueRelaxedStateData, error) {
+	return ReadParquetData[UniqueRelaxedStateData](dataFile)
+}
+
+// Read the network summary data file (e.g. networkSummary.pq), which holds exactly one row.
+//
+// # Returns
+//
+// (summary, nil) on success, (nil, error) on errors or if the file does not hold exactly one row
+func ReadHopfieldNetworkSummary(dataFile string) (*HopfieldNetworkSummaryData, error) {
+	rows, err := ReadParquetData[HopfieldNetworkSummaryData](dataFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) != 1 {
+		return nil, fmt.Errorf("%v: expected one network summary, found %d", dataFile, len(rows))
+	}
+	return &rows[0], nil
+}
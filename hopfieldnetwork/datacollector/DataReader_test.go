@@ -0,0 +1,69 @@
+package datacollector
+
+import (
+	"path"
+	"testing"
+)
+
+// Test that iterating a data file returns every row in order, across several read batches.
+func TestIteratorReadsAllRows(t *testing.T) {
+	dataFile := path.Join(t.TempDir(), "learnStateData.pq")
+	numRows := 2*PARQUET_READ_BATCH_SIZE + 7
+	rows := make([]*LearnStateData, numRows)
+	for rowIndex := range rows {
+		rows[rowIndex] = &LearnStateData{
+			Epoch:         rowIndex,
+			EnergyProfile: []float64{float64(rowIndex), -1},
+			Stable:        rowIndex%2 == 0,
+		}
+	}
+	if err := writeDataRows(dataFile, DataFileSettings{}, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	iterator, err := NewLearnStateIterator(dataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iterator.Close()
+	if iterator.NumRows() != int64(numRows) {
+		t.Errorf("iterator has %v rows, expected %v", iterator.NumRows(), numRows)
+	}
+
+	rowIndex := 0
+	for iterator.Next() {
+		row := iterator.Row()
+		if row.Epoch != rowIndex || row.EnergyProfile[0] != float64(rowIndex) || row.Stable != (rowIndex%2 == 0) {
+			t.Fatalf("row %v read as %+v", rowIndex, row)
+		}
+		rowIndex += 1
+	}
+	if err := iterator.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if rowIndex != numRows {
+		t.Errorf("iterated %v rows, expected %v", rowIndex, numRows)
+	}
+}
+
+// Test that the network summary is read back as written.
+func TestReadHopfieldNetworkSummary(t *testing.T) {
+	dataFile := path.Join(t.TempDir(), "networkSummary.pq")
+	summary := &HopfieldNetworkSummaryData{
+		NetworkDomain:    "BipolarDomain",
+		NetworkDimension: 100,
+		LearningRate:     0.5,
+		TargetStates:     3,
+	}
+	if err := WriteHopfieldNetworkSummary(dataFile, DataFileSettings{}, summary); err != nil {
+		t.Fatal(err)
+	}
+
+	readSummary, err := ReadHopfieldNetworkSummary(dataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *readSummary != *summary {
+		t.Errorf("summary read as %+v, expected %+v", readSummary, summary)
+	}
+}
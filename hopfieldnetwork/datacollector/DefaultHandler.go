@@ -1,42 +1,62 @@
 package datacollector
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/xitongsys/parquet-go-source/local"
-	"github.com/xitongsys/parquet-go/parquet"
 	"github.com/xitongsys/parquet-go/source"
 	"github.com/xitongsys/parquet-go/writer"
 )
 
 // Define the handleEvent function to be a template across all handlers
-type handleEventFn func(*writer.ParquetWriter, interface{})
+type handleEventFn[T Event] func(dataFileWriter, T) error
 
-type cleanupFn func(*writer.ParquetWriter)
+type cleanupFn func(dataFileWriter) error
 
-func defaultCleanupFn(*writer.ParquetWriter) {}
+func defaultCleanupFn(dataFileWriter) error { return nil }
 
-// A dataHandler specifies what events it listens to, and what to do when that event occurs
-type dataHandler struct {
-	eventID     int
-	dataWriter  *writer.ParquetWriter
-	fileHandle  source.ParquetFile
-	handleEvent handleEventFn
-	cleanupFn   cleanupFn
+// A dataFileHandler is a DataHandler writing events of type T to a data file, in one of the output formats.
+// It specifies what to do when that event occurs, the event type itself is given by T
+type dataFileHandler[T Event] struct {
+	dataFilePath string
+	dataWriter   dataFileWriter
+	handleEvent  handleEventFn[T]
+	cleanupFn    cleanupFn
 }
 
-func (handler *dataHandler) getEventID() int {
-	return handler.eventID
+// Create a new dataFileHandler writing to the given file with the given settings, wrapping the writer creation errors.
+func newDataFileHandler[T Event](dataFilePath string, settings DataFileSettings, dataStruct interface{}, handleEvent handleEventFn[T], cleanupFn cleanupFn) (DataHandler, error) {
+	dataWriter, err := newDataFileWriter(dataFilePath, settings, dataStruct)
+	if err != nil {
+		return nil, err
+	}
+	return &dataFileHandler[T]{
+		dataFilePath: dataFilePath,
+		dataWriter:   dataWriter,
+		handleEvent:  handleEvent,
+		cleanupFn:    cleanupFn,
+	}, nil
 }
 
-func (handler *dataHandler) writeStop() error {
-	handler.cleanupFn(handler.dataWriter)
+func (handler *dataFileHandler[T]) EventTypes() []DataCollectionEventEnum {
+	return []DataCollectionEventEnum{eventTypeOf[T]()}
+}
 
-	if err := handler.dataWriter.WriteStop(); err != nil {
-		return err
+func (handler *dataFileHandler[T]) Handle(event Event) error {
+	if err := handler.handleEvent(handler.dataWriter, event.(T)); err != nil {
+		return fmt.Errorf("%v: %w", handler.dataFilePath, err)
 	}
-	if err := handler.fileHandle.Close(); err != nil {
-		return err
+	return nil
+}
+
+func (handler *dataFileHandler[T]) Close() error {
+	if err := handler.cleanupFn(handler.dataWriter); err != nil {
+		handler.dataWriter.Close()
+		return fmt.Errorf("%v: %w", handler.dataFilePath, err)
+	}
+	if err := handler.dataWriter.Close(); err != nil {
+		return fmt.Errorf("%v: %w", handler.dataFilePath, err)
 	}
 	return nil
 }
@@ -57,17 +77,51 @@ func (handler *dataHandler) writeStop() error {
 // dataStruct (generic struct): A valid struct for writing in the parquet format.
 // Should be called with `new(dataStruct)` as argument.
 //
+// settings ParquetWriterSettings: The compression, row group size, page size, and parallelism of the writer.
+// Zero values select the defaults.
+//
 // # Returns
 //
-// A ParquetWriter to the data file in question.
-func newParquetWriter[T interface{}](dataFilePath string, dataStruct T) (source.ParquetFile, *writer.ParquetWriter) {
+// (file handle, ParquetWriter to the data file in question, nil) on success, or (nil, nil, error) if the
+// file could not be created. The error includes the data file path.
+func newParquetWriter[T interface{}](dataFilePath string, dataStruct T, settings ParquetWriterSettings) (source.ParquetFile, *writer.ParquetWriter, error) {
 	os.Remove(dataFilePath)
-	dataFileWriter, _ := local.NewLocalFileWriter(dataFilePath)
-	parquetDataWriter, _ := writer.NewParquetWriter(dataFileWriter, dataStruct, 8)
-	parquetDataWriter.RowGroupSize = 128 * 1024 * 1024 //128MB
-	parquetDataWriter.PageSize = 8 * 1024              //8K
-	parquetDataWriter.CompressionType = parquet.CompressionCodec_SNAPPY
-	parquetDataWriter.Flush(true)
+	dataFileWriter, err := local.NewLocalFileWriter(dataFilePath)
+	if err != nil {
+		// File system errors already include the data file path
+		return nil, nil, err
+	}
+	settings = settings.withDefaults()
+	parquetDataWriter, err := writer.NewParquetWriter(dataFileWriter, dataStruct, settings.Parallelism)
+	if err != nil {
+		dataFileWriter.Close()
+		return nil, nil, fmt.Errorf("%v: %w", dataFilePath, err)
+	}
+	parquetDataWriter.RowGroupSize = settings.RowGroupSizeMB * 1024 * 1024
+	parquetDataWriter.PageSize = settings.PageSizeKB * 1024
+	parquetDataWriter.CompressionType = settings.Compression.compressionCodec()
+	if err := parquetDataWriter.Flush(true); err != nil {
+		dataFileWriter.Close()
+		return nil, nil, fmt.Errorf("%v: %w", dataFilePath, err)
+	}
 
-	return dataFileWriter, parquetDataWriter
+	return dataFileWriter, parquetDataWriter, nil
+}
+
+// Write a collection of rows to a new data file in one go, for small files such as summaries.
+func writeDataRows[T interface{}](dataFilePath string, settings DataFileSettings, rows []*T) error {
+	dataWriter, err := newDataFileWriter(dataFilePath, settings, new(T))
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := dataWriter.Write(row); err != nil {
+			dataWriter.Close()
+			return fmt.Errorf("%v: %w", dataFilePath, err)
+		}
+	}
+	if err := dataWriter.Close(); err != nil {
+		return fmt.Errorf("%v: %w", dataFilePath, err)
+	}
+	return nil
 }
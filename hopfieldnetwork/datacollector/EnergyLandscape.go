@@ -0,0 +1,46 @@
+package datacollector
+
+// Representation of a local minimum of an exhaustively enumerated energy landscape, as computed by the landscape command
+// StateIndex is the index of the minimum in the enumeration of all states, where unit i is set if bit i of the index is set
+// State is the state vector of the minimum
+// Energy is the energy of the minimum
+// BasinSize is the number of states that descend to this minimum (including the minimum itself)
+// BasinFraction is the fraction of all states that descend to this minimum
+// AttractorClass is the classification of the minimum by its relation to the target states (see hopfieldnetwork.AttractorClassEnum)
+// MixtureTargets and MixtureSigns are the indices and signs of the target states composing the minimum
+type LandscapeMinimumData struct {
+	StateIndex     int       `parquet:"name=StateIndex, type=INT32"`
+	State          []float64 `parquet:"name=State, type=DOUBLE, repetitiontype=REPEATED"`
+	Energy         float64   `parquet:"name=Energy, type=DOUBLE"`
+	BasinSize      int       `parquet:"name=BasinSize, type=INT32"`
+	BasinFraction  float64   `parquet:"name=BasinFraction, type=DOUBLE"`
+	AttractorClass string    `parquet:"name=AttractorClass, type=BYTE_ARRAY, convertedtype=UTF8"`
+	MixtureTargets []int     `parquet:"name=MixtureTargets, type=INT32, repetitiontype=REPEATED"`
+	MixtureSigns   []int     `parquet:"name=MixtureSigns, type=INT32, repetitiontype=REPEATED"`
+}
+
+// Representation of a target state within an exhaustively enumerated energy landscape, as computed by the landscape command
+// TargetIndex is the index of the target state
+// StateIndex is the index of the target state in the enumeration of all states
+// Energy is the energy of the target state
+// IsMinimum is true if the target state is a local minimum
+// MinimumStateIndex is the state index of the local minimum the target state descends to (the target state itself if it is a minimum)
+// BasinSize is the number of states that descend to that minimum
+type LandscapeTargetData struct {
+	TargetIndex       int     `parquet:"name=TargetIndex, type=INT32"`
+	StateIndex        int     `parquet:"name=StateIndex, type=INT32"`
+	Energy            float64 `parquet:"name=Energy, type=DOUBLE"`
+	IsMinimum         bool    `parquet:"name=IsMinimum, type=BOOLEAN"`
+	MinimumStateIndex int     `parquet:"name=MinimumStateIndex, type=INT32"`
+	BasinSize         int     `parquet:"name=BasinSize, type=INT32"`
+}
+
+// Write the local minima of an energy landscape to the specified data file, in the given output format
+func WriteLandscapeMinima(dataFile string, settings DataFileSettings, minimumData []*LandscapeMinimumData) error {
+	return writeDataRows(dataFile, settings, minimumData)
+}
+
+// Write the target states of an energy landscape to the specified data file, in the given output format
+func WriteLandscapeTargets(dataFile string, settings DataFileSettings, targetData []*LandscapeTargetData) error {
+	return writeDataRows(dataFile, settings, targetData)
+}
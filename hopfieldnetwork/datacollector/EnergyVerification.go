@@ -0,0 +1,51 @@
+package datacollector
+
+// Representation of the verification that relaxation never increases the energy of a state, as computed by the verify command
+// NetworkDimension is the dimension of the network
+// RelativeAsymmetry is the Frobenius norm of W - W^T relative to the norm of W, 0 for a symmetric matrix
+// MaximumDiagonalMagnitude is the largest absolute value on the diagonal, 0 for a zero-diagonal matrix
+// MinimumDiagonal is the smallest diagonal value. Energy descent is only guaranteed if this is not negative (and the matrix is symmetric)
+// ProbeStates is the number of probe states relaxed
+// CheckedUpdates is the number of unit updates that changed a unit, each of which was checked
+// EnergyViolations is the number of checked updates that increased the energy of the state
+// ViolatingProbes is the number of probe states with at least one violation
+// MaximumEnergyIncrease is the largest increase in energy of a single update
+// EnergyMonotonic is true if no update increased the energy
+type EnergyVerificationData struct {
+	NetworkDimension         int     `parquet:"name=NetworkDimension, type=INT32"`
+	RelativeAsymmetry        float64 `parquet:"name=RelativeAsymmetry, type=DOUBLE"`
+	MaximumDiagonalMagnitude float64 `parquet:"name=MaximumDiagonalMagnitude, type=DOUBLE"`
+	MinimumDiagonal          float64 `parquet:"name=MinimumDiagonal, type=DOUBLE"`
+	ProbeStates              int     `parquet:"name=ProbeStates, type=INT32"`
+	CheckedUpdates           int64   `parquet:"name=CheckedUpdates, type=INT64"`
+	EnergyViolations         int64   `parquet:"name=EnergyViolations, type=INT64"`
+	ViolatingProbes          int     `parquet:"name=ViolatingProbes, type=INT32"`
+	MaximumEnergyIncrease    float64 `parquet:"name=MaximumEnergyIncrease, type=DOUBLE"`
+	EnergyMonotonic          bool    `parquet:"name=EnergyMonotonic, type=BOOLEAN"`
+}
+
+// Representation of the energy descent verification of a single probe state
+// ProbeIndex is the index of the probe state
+// Stable is true if the probe state relaxed to a stable state
+// Sweeps is the number of sweeps made relaxing the probe state
+// CheckedUpdates is the number of unit updates that changed a unit
+// EnergyViolations is the number of checked updates that increased the energy of the state
+// MaximumEnergyIncrease is the largest increase in energy of a single update, 0 if there were no violations
+type EnergyVerificationProbeData struct {
+	ProbeIndex            int     `parquet:"name=ProbeIndex, type=INT32"`
+	Stable                bool    `parquet:"name=Stable, type=BOOLEAN"`
+	Sweeps                int     `parquet:"name=Sweeps, type=INT32"`
+	CheckedUpdates        int     `parquet:"name=CheckedUpdates, type=INT32"`
+	EnergyViolations      int     `parquet:"name=EnergyViolations, type=INT32"`
+	MaximumEnergyIncrease float64 `parquet:"name=MaximumEnergyIncrease, type=DOUBLE"`
+}
+
+// Write the summary of an energy descent verification to the specified data file, in the given output format
+func WriteEnergyVerification(dataFile string, settings DataFileSettings, verificationData *EnergyVerificationData) error {
+	return writeDataRows(dataFile, settings, []*EnergyVerificationData{verificationData})
+}
+
+// Write the energy descent verification of each probe state to the specified data file, in the given output format
+func WriteEnergyVerificationProbes(dataFile string, settings DataFileSettings, probeData []*EnergyVerificationProbeData) error {
+	return writeDataRows(dataFile, settings, probeData)
+}
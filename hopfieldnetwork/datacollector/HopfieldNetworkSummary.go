@@ -8,9 +8,12 @@ package datacollector
 // LearningNoiseScale is the scale of the noise applied to the network
 // UnitsUpdated is the amount of units updated at each step
 // ForceSymmetricWeightMatrix is a boolean flag indicating if the network is allowed to take asymmetric values
+// DistanceMeasure is the measure of distance from relaxed states to target states (as a string)
 // Threads is the number of threads the network used to relax states
 // TargetStates is the number of states used for learning
 // ProbeStates is the number of states used for probing
+// OutputFormat is the format of the data files (as a string)
+// ParquetCompression, ParquetRowGroupSizeMB, ParquetPageSizeKB, and ParquetParallelism are the settings of the parquet writers
 type HopfieldNetworkSummaryData struct {
 	NetworkDomain               string  `parquet:"name=NetworkDomain, type=BYTE_ARRAY"`
 	NetworkDimension            int     `parquet:"name=NetworkDimension, type=INT32"`
@@ -23,20 +26,18 @@ type HopfieldNetworkSummaryData struct {
 	UnitsUpdated                int     `parquet:"name=UnitsUpdated, type=INT32"`
 	ForceSymmetricWeightMatrix  bool    `parquet:"name=ForceSymmetricWeightMatrix, type=BOOLEAN"`
 	ForceZeroBias               bool    `parquet:"name=ForceZeroBias, type=BOOLEAN"`
+	DistanceMeasure             string  `parquet:"name=DistanceMeasure, type=BYTE_ARRAY, convertedtype=UTF8"`
 	Threads                     int     `parquet:"name=Threads, type=INT32"`
 	TargetStates                int     `parquet:"name=TargetStates, type=INT32"`
 	ProbeStates                 int     `parquet:"name=ProbeStates, type=INT32"`
+	OutputFormat                string  `parquet:"name=OutputFormat, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ParquetCompression          string  `parquet:"name=ParquetCompression, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ParquetRowGroupSizeMB       int64   `parquet:"name=ParquetRowGroupSizeMB, type=INT64"`
+	ParquetPageSizeKB           int64   `parquet:"name=ParquetPageSizeKB, type=INT64"`
+	ParquetParallelism          int64   `parquet:"name=ParquetParallelism, type=INT64"`
 }
 
-// Write the HopfieldNetworkSummary struct to the specified data file, in a parquet format
-func WriteHopfieldNetworkSummary(dataFile string, summaryData *HopfieldNetworkSummaryData) error {
-	fileHandle, dataWriter := newParquetWriter(dataFile, new(HopfieldNetworkSummaryData))
-	dataWriter.Write(summaryData)
-	if err := dataWriter.WriteStop(); err != nil {
-		return err
-	}
-	if err := fileHandle.Close(); err != nil {
-		return err
-	}
-	return nil
+// Write the HopfieldNetworkSummary struct to the specified data file, with the given data file settings
+func WriteHopfieldNetworkSummary(dataFile string, settings DataFileSettings, summaryData *HopfieldNetworkSummaryData) error {
+	return writeDataRows(dataFile, settings, []*HopfieldNetworkSummaryData{summaryData})
 }
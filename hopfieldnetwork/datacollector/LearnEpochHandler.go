@@ -0,0 +1,38 @@
+package datacollector
+
+// Representation of the weight matrix of a network after a single epoch of learning.
+//
+// The matrix is measured before it is normalized at the end of learning (see HopfieldNetwork.LearnStates), so even the
+// last epoch does not match the saved and probed matrix. Normalization divides the matrix by its Frobenius norm (so the
+// saved matrix has norm 1), which scales FrobeniusNorm, MaximumWeight, MinimumWeight, MaximumDiagonalMagnitude and
+// TopEigenvalues by the same factor, while RelativeAsymmetry is unchanged.
+//
+// Epoch is the index of the epoch, counted over all learning the network has done.
+// FrobeniusNorm is the Frobenius norm of the weight matrix.
+// MaximumWeight and MinimumWeight are the largest and smallest weights.
+// RelativeAsymmetry is the Frobenius norm of W - W^T relative to the norm of W, 0 for a symmetric matrix.
+// MaximumDiagonalMagnitude is the largest absolute weight on the diagonal.
+// TopEigenvalues are the largest eigenvalues of the symmetric part of the weight matrix, in descending order.
+type LearnEpochData struct {
+	Epoch                    int       `parquet:"name=Epoch, type=INT32"`
+	FrobeniusNorm            float64   `parquet:"name=FrobeniusNorm, type=DOUBLE"`
+	MaximumWeight            float64   `parquet:"name=MaximumWeight, type=DOUBLE"`
+	MinimumWeight            float64   `parquet:"name=MinimumWeight, type=DOUBLE"`
+	RelativeAsymmetry        float64   `parquet:"name=RelativeAsymmetry, type=DOUBLE"`
+	MaximumDiagonalMagnitude float64   `parquet:"name=MaximumDiagonalMagnitude, type=DOUBLE"`
+	TopEigenvalues           []float64 `parquet:"name=TopEigenvalues, type=DOUBLE, repetitiontype=REPEATED"`
+}
+
+func (data *LearnEpochData) EventType() DataCollectionEventEnum {
+	return DataCollectionEvent_LearnEpoch
+}
+
+func (data *LearnEpochData) isEvent() {}
+
+func NewLearnEpochHandler(dataFile string, settings DataFileSettings) (DataHandler, error) {
+	return newDataFileHandler(dataFile, settings, new(LearnEpochData), handleLearnEpochEvent, defaultCleanupFn)
+}
+
+func handleLearnEpochEvent(dataWriter dataFileWriter, event *LearnEpochData) error {
+	return dataWriter.Write(event)
+}
@@ -1,9 +1,5 @@
 package datacollector
 
-import (
-	"github.com/xitongsys/parquet-go/writer"
-)
-
 // Representation of the result of a relaxation of a state.
 //
 // State is the state vector that has been relaxed.
@@ -18,18 +14,16 @@ type LearnStateData struct {
 	Stable           bool      `parquet:"name=Stable, type=BOOLEAN"`
 }
 
-func NewLearnStateHandler(dataFile string) *dataHandler {
-	fileHandle, dataWriter := newParquetWriter(dataFile, new(LearnStateData))
-	return &dataHandler{
-		eventID:     DataCollectionEvent_LearnState,
-		dataWriter:  dataWriter,
-		fileHandle:  fileHandle,
-		handleEvent: handleLearnStateEvent,
-		cleanupFn:   defaultCleanupFn,
-	}
+func (data *LearnStateData) EventType() DataCollectionEventEnum {
+	return DataCollectionEvent_LearnState
+}
+
+func (data *LearnStateData) isEvent() {}
+
+func NewLearnStateHandler(dataFile string, settings DataFileSettings) (DataHandler, error) {
+	return newDataFileHandler(dataFile, settings, new(LearnStateData), handleLearnStateEvent, defaultCleanupFn)
 }
 
-func handleLearnStateEvent(writer *writer.ParquetWriter, event interface{}) {
-	result := event.(LearnStateData)
-	writer.Write(result)
+func handleLearnStateEvent(dataWriter dataFileWriter, event *LearnStateData) error {
+	return dataWriter.Write(event)
 }
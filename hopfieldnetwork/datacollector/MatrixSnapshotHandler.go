@@ -0,0 +1,66 @@
+package datacollector
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/hmcalister/gonum-matrix-io/pkg/gonumio"
+	"gonum.org/v1/gonum/mat"
+)
+
+// The format of the file name of each matrix snapshot, given the epoch of the snapshot
+const MATRIX_SNAPSHOT_FILE_FORMAT = "epoch%06d.bin"
+
+// A copy of the weight matrix of a network after a single epoch of learning.
+//
+// Epoch is the index of the epoch, counted over all learning the network has done.
+// Matrix is a copy of the weight matrix, which is not modified by later learning. The snapshot is taken before the matrix is
+// normalized at the end of learning, so it differs from the saved matrix by a constant factor.
+type MatrixSnapshotData struct {
+	Epoch  int
+	Matrix *mat.Dense
+}
+
+func (data *MatrixSnapshotData) EventType() DataCollectionEventEnum {
+	return DataCollectionEvent_MatrixSnapshot
+}
+
+func (data *MatrixSnapshotData) isEvent() {}
+
+// A DataHandler writing each matrix snapshot to a separate gonumio binary file.
+type matrixSnapshotHandler struct {
+	snapshotDirectory string
+}
+
+// Create a handler writing each matrix snapshot to a gonumio binary file (named by MATRIX_SNAPSHOT_FILE_FORMAT) in a directory.
+//
+// # Arguments
+//
+// snapshotDirectory string: The directory to write the snapshots to, which is created if it does not exist
+//
+// # Returns
+//
+// (DataHandler, nil) on success, (nil, error) if the directory could not be created
+func NewMatrixSnapshotHandler(snapshotDirectory string) (DataHandler, error) {
+	if err := os.MkdirAll(snapshotDirectory, 0700); err != nil {
+		return nil, err
+	}
+	return &matrixSnapshotHandler{
+		snapshotDirectory: snapshotDirectory,
+	}, nil
+}
+
+func (handler *matrixSnapshotHandler) EventTypes() []DataCollectionEventEnum {
+	return []DataCollectionEventEnum{DataCollectionEvent_MatrixSnapshot}
+}
+
+func (handler *matrixSnapshotHandler) Handle(event Event) error {
+	snapshot := event.(*MatrixSnapshotData)
+	// File system errors already include the file path
+	return gonumio.SaveMatrix(snapshot.Matrix, path.Join(handler.snapshotDirectory, fmt.Sprintf(MATRIX_SNAPSHOT_FILE_FORMAT, snapshot.Epoch)))
+}
+
+func (handler *matrixSnapshotHandler) Close() error {
+	return nil
+}
@@ -0,0 +1,58 @@
+package datacollector
+
+import "hmcalister/hopfield/hopfieldutils"
+
+// The format data files are written in.
+//
+// Each format writes the same columns, named as in the parquet schema of the data struct.
+type OutputFormatEnum int
+
+const (
+	// Write parquet files. Repeated fields are parquet lists.
+	ParquetFormat OutputFormatEnum = iota
+
+	// Write comma separated values with a header row. Repeated fields are written as a single
+	// value, with elements separated by semicolons (e.g. "1;-1;1").
+	CSVFormat OutputFormatEnum = iota
+
+	// Write JSON Lines, one JSON object per row. Repeated fields are JSON arrays.
+	JSONLinesFormat OutputFormatEnum = iota
+)
+
+// All valid OutputFormatEnum values, used when parsing output formats from strings.
+var outputFormatEnumValues = []OutputFormatEnum{
+	ParquetFormat,
+	CSVFormat,
+	JSONLinesFormat,
+}
+
+// Parse an OutputFormatEnum from either its name (e.g. "CSVFormat", case insensitive) or its integer value.
+func ParseOutputFormatEnum(name string) (OutputFormatEnum, error) {
+	return hopfieldutils.ParseEnum(name, outputFormatEnumValues)
+}
+
+// Implement encoding.TextMarshaler so output formats are written by name in configuration files.
+func (i OutputFormatEnum) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// Implement encoding.TextUnmarshaler so output formats can be read by name from configuration files.
+func (i *OutputFormatEnum) UnmarshalText(text []byte) error {
+	parsedFormat, err := ParseOutputFormatEnum(string(text))
+	if err != nil {
+		return err
+	}
+	*i = parsedFormat
+	return nil
+}
+
+// Get the file extension (including the leading dot) of data files written in this format.
+func (i OutputFormatEnum) FileExtension() string {
+	fileExtensions := map[OutputFormatEnum]string{
+		ParquetFormat:   ".pq",
+		CSVFormat:       ".csv",
+		JSONLinesFormat: ".jsonl",
+	}
+
+	return fileExtensions[i]
+}
@@ -0,0 +1,235 @@
+package datacollector
+
+import (
+	"fmt"
+
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+)
+
+// The number of rows read from a parquet data file at once by a ParquetIterator
+const PARQUET_READ_BATCH_SIZE = 1024
+
+// A ParquetIterator streams the rows of a parquet data file into a data struct, without reading the entire file into memory.
+//
+// This is the counterpart to the handlers in this package, so for example a file written by
+// the RelaxationResultHandler can be read with `NewParquetIterator[RelaxationResultData](dataFile)`.
+// Rows are read in batches of PARQUET_READ_BATCH_SIZE. Use in the same manner as a bufio.Scanner:
+//
+//	iterator, err := NewParquetIterator[RelaxationResultData](dataFile)
+//	if err != nil {
+//		return err
+//	}
+//	defer iterator.Close()
+//	for iterator.Next() {
+//		result := iterator.Row()
+//		...
+//	}
+//	if err := iterator.Err(); err != nil {
+//		return err
+//	}
+type ParquetIterator[T interface{}] struct {
+	dataFile      string
+	fileReader    source.ParquetFile
+	parquetReader *reader.ParquetReader
+	rowsRemaining int64
+	batch         []T
+	batchIndex    int
+	err           error
+}
+
+// Open a parquet data file for iteration over its rows.
+//
+// # Arguments
+//
+// dataFile string: The path to the data file to read
+//
+// # Returns
+//
+// (ParquetIterator positioned before the first row, nil) on success, (nil, error) on errors.
+// The iterator must be closed once finished.
+func NewParquetIterator[T interface{}](dataFile string) (*ParquetIterator[T], error) {
+	fileReader, err := local.NewLocalFileReader(dataFile)
+	if err != nil {
+		return nil, err
+	}
+
+	parquetReader, err := reader.NewParquetReader(fileReader, new(T), 4)
+	if err != nil {
+		fileReader.Close()
+		return nil, fmt.Errorf("%v: %w", dataFile, err)
+	}
+
+	return &ParquetIterator[T]{
+		dataFile:      dataFile,
+		fileReader:    fileReader,
+		parquetReader: parquetReader,
+		rowsRemaining: parquetReader.GetNumRows(),
+	}, nil
+}
+
+// Get the total number of rows in the data file.
+func (iterator *ParquetIterator[T]) NumRows() int64 {
+	return iterator.parquetReader.GetNumRows()
+}
+
+// Advance to the next row, which is then available through Row.
+//
+// # Returns
+//
+// True if there is a next row, false once all rows are read or an error occurred (see Err)
+func (iterator *ParquetIterator[T]) Next() bool {
+	if iterator.err != nil {
+		return false
+	}
+	iterator.batchIndex += 1
+	if iterator.batchIndex < len(iterator.batch) {
+		return true
+	}
+	if iterator.rowsRemaining <= 0 {
+		iterator.batch = nil
+		return false
+	}
+
+	// A new batch is allocated each time, so rows remain valid after the iterator advances
+	batchSize := int64(PARQUET_READ_BATCH_SIZE)
+	if iterator.rowsRemaining < batchSize {
+		batchSize = iterator.rowsRemaining
+	}
+	iterator.batch = make([]T, batchSize)
+	if err := iterator.parquetReader.Read(&iterator.batch); err != nil {
+		iterator.batch = nil
+		iterator.err = fmt.Errorf("%v: %w", iterator.dataFile, err)
+		return false
+	}
+	iterator.rowsRemaining -= batchSize
+	iterator.batchIndex = 0
+	return true
+}
+
+// Get the current row. Only valid after a call to Next has returned true.
+//
+// The row is not modified by later calls to Next, so may be kept.
+func (iterator *ParquetIterator[T]) Row() *T {
+	return &iterator.batch[iterator.batchIndex]
+}
+
+// Get the error that stopped iteration, or nil if iteration has not failed.
+func (iterator *ParquetIterator[T]) Err() error {
+	return iterator.err
+}
+
+// Close the data file.
+func (iterator *ParquetIterator[T]) Close() error {
+	iterator.parquetReader.ReadStop()
+	return iterator.fileReader.Close()
+}
+
+// Read all rows of a parquet data file into a slice of the given data struct.
+//
+// This is the counterpart to the handlers in this package, so for example a file written by
+// the RelaxationResultHandler can be read with `ReadParquetData[RelaxationResultData](dataFile)`.
+// Be aware the entire file is read into memory! Use a ParquetIterator for large files.
+//
+// # Arguments
+//
+// dataFile string: The path to the data file to read
+//
+// # Returns
+//
+// (slice of rows, nil) on success, (nil, error) on errors
+func ReadParquetData[T interface{}](dataFile string) ([]T, error) {
+	iterator, err := NewParquetIterator[T](dataFile)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	rows := make([]T, 0, iterator.NumRows())
+	for iterator.Next() {
+		rows = append(rows, *iterator.Row())
+	}
+	if err := iterator.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// A column of a parquet data file, as recorded in the schema of that file.
+//
+// Name is the name of the column.
+// Type is the physical type of the column, along with the converted type if present (e.g. "BYTE_ARRAY (UTF8)").
+// Repeated is true if the column holds a list of values in each row.
+type ParquetColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Repeated bool   `json:"repeated,omitempty"`
+}
+
+// The metadata of a parquet data file, as recorded in the footer of that file.
+//
+// Columns are the columns of the file schema.
+// Rows is the total number of rows.
+// RowGroups is the number of row groups the rows are stored in.
+// Compression is the compression codec of the column chunks (e.g. "SNAPPY"), or empty if the file has no row groups.
+type ParquetMetadata struct {
+	Columns     []ParquetColumn
+	Rows        int64
+	RowGroups   int
+	Compression string
+}
+
+// Read the metadata of a parquet data file, without reading any rows.
+//
+// # Arguments
+//
+// dataFile string: The path to the data file to read
+//
+// # Returns
+//
+// (metadata of the file, nil) on success, (nil, error) on errors
+func ReadParquetMetadata(dataFile string) (*ParquetMetadata, error) {
+	fileReader, err := local.NewLocalFileReader(dataFile)
+	if err != nil {
+		return nil, err
+	}
+	defer fileReader.Close()
+
+	parquetReader, err := reader.NewParquetReader(fileReader, nil, 1)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", dataFile, err)
+	}
+	defer parquetReader.ReadStop()
+
+	metadata := &ParquetMetadata{
+		Columns:   []ParquetColumn{},
+		Rows:      parquetReader.GetNumRows(),
+		RowGroups: len(parquetReader.Footer.GetRowGroups()),
+	}
+	// The first schema element is the root of the schema, not a column
+	for _, element := range parquetReader.SchemaHandler.SchemaElements[1:] {
+		column := ParquetColumn{
+			Name:     element.GetName(),
+			Repeated: element.IsSetRepetitionType() && element.GetRepetitionType() == parquet.FieldRepetitionType_REPEATED,
+		}
+		if element.IsSetType() {
+			column.Type = element.GetType().String()
+		}
+		if element.IsSetConvertedType() {
+			column.Type = fmt.Sprintf("%v (%v)", column.Type, element.GetConvertedType())
+		}
+		metadata.Columns = append(metadata.Columns, column)
+	}
+	// Every column chunk is written with the same codec, so the first chunk describes the file
+	for _, rowGroup := range parquetReader.Footer.GetRowGroups() {
+		for _, columnChunk := range rowGroup.GetColumns() {
+			if columnChunk.IsSetMetaData() {
+				metadata.Compression = columnChunk.GetMetaData().GetCodec().String()
+				return metadata, nil
+			}
+		}
+	}
+	return metadata, nil
+}
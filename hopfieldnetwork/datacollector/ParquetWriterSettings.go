@@ -0,0 +1,123 @@
+package datacollector
+
+import (
+	"hmcalister/hopfield/hopfieldutils"
+
+	"github.com/xitongsys/parquet-go/parquet"
+)
+
+const (
+	DEFAULT_PARQUET_ROW_GROUP_SIZE_MB = 128
+	DEFAULT_PARQUET_PAGE_SIZE_KB      = 8
+	DEFAULT_PARQUET_PARALLELISM       = 8
+)
+
+// The compression codec of parquet data files.
+type ParquetCompressionEnum int
+
+const (
+	// Snappy compression, fast with a moderate compression ratio.
+	SnappyCompression ParquetCompressionEnum = iota
+
+	// Gzip compression, slower with a better compression ratio.
+	GzipCompression ParquetCompressionEnum = iota
+
+	// Zstandard compression, a better compression ratio than snappy at a similar speed.
+	ZstdCompression ParquetCompressionEnum = iota
+
+	// No compression.
+	NoCompression ParquetCompressionEnum = iota
+)
+
+// All valid ParquetCompressionEnum values, used when parsing compression codecs from strings.
+var parquetCompressionEnumValues = []ParquetCompressionEnum{
+	SnappyCompression,
+	GzipCompression,
+	ZstdCompression,
+	NoCompression,
+}
+
+// Parse a ParquetCompressionEnum from either its name (e.g. "ZstdCompression", case insensitive) or its integer value.
+func ParseParquetCompressionEnum(name string) (ParquetCompressionEnum, error) {
+	return hopfieldutils.ParseEnum(name, parquetCompressionEnumValues)
+}
+
+// Implement encoding.TextMarshaler so compression codecs are written by name in configuration files.
+func (i ParquetCompressionEnum) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// Implement encoding.TextUnmarshaler so compression codecs can be read by name from configuration files.
+func (i *ParquetCompressionEnum) UnmarshalText(text []byte) error {
+	parsedCompression, err := ParseParquetCompressionEnum(string(text))
+	if err != nil {
+		return err
+	}
+	*i = parsedCompression
+	return nil
+}
+
+// Get the parquet compression codec of a ParquetCompressionEnum
+func (i ParquetCompressionEnum) compressionCodec() parquet.CompressionCodec {
+	compressionCodecs := map[ParquetCompressionEnum]parquet.CompressionCodec{
+		SnappyCompression: parquet.CompressionCodec_SNAPPY,
+		GzipCompression:   parquet.CompressionCodec_GZIP,
+		ZstdCompression:   parquet.CompressionCodec_ZSTD,
+		NoCompression:     parquet.CompressionCodec_UNCOMPRESSED,
+	}
+
+	return compressionCodecs[i]
+}
+
+// Settings of the writers of parquet data files.
+//
+// Compression is the compression codec of every column.
+// RowGroupSizeMB is the (approximate, uncompressed) size of each row group in megabytes. Larger row groups compress
+// better, but are held in memory while writing.
+// PageSizeKB is the size of each page within a column chunk in kilobytes.
+// Parallelism is the number of goroutines encoding each row group.
+//
+// A zero RowGroupSizeMB, PageSizeKB or Parallelism selects the default value, so the zero ParquetWriterSettings
+// is the default settings.
+type ParquetWriterSettings struct {
+	Compression    ParquetCompressionEnum `json:"compression" yaml:"compression"`
+	RowGroupSizeMB int64                  `json:"rowGroupSizeMB" yaml:"rowGroupSizeMB"`
+	PageSizeKB     int64                  `json:"pageSizeKB" yaml:"pageSizeKB"`
+	Parallelism    int64                  `json:"parallelism" yaml:"parallelism"`
+}
+
+// Get the default settings of the writers of parquet data files.
+func DefaultParquetWriterSettings() ParquetWriterSettings {
+	return ParquetWriterSettings{
+		Compression:    SnappyCompression,
+		RowGroupSizeMB: DEFAULT_PARQUET_ROW_GROUP_SIZE_MB,
+		PageSizeKB:     DEFAULT_PARQUET_PAGE_SIZE_KB,
+		Parallelism:    DEFAULT_PARQUET_PARALLELISM,
+	}
+}
+
+// Get the settings with each zero value replaced by the default value.
+func (settings ParquetWriterSettings) withDefaults() ParquetWriterSettings {
+	defaults := DefaultParquetWriterSettings()
+	if settings.RowGroupSizeMB == 0 {
+		settings.RowGroupSizeMB = defaults.RowGroupSizeMB
+	}
+	if settings.PageSizeKB == 0 {
+		settings.PageSizeKB = defaults.PageSizeKB
+	}
+	if settings.Parallelism == 0 {
+		settings.Parallelism = defaults.Parallelism
+	}
+	return settings
+}
+
+// The settings of the data files written by handlers (and summary writers).
+//
+// OutputFormat is the format of the data files.
+// Parquet is the settings of the parquet writers, only used if OutputFormat is ParquetFormat.
+//
+// The zero DataFileSettings writes parquet files with the default settings.
+type DataFileSettings struct {
+	OutputFormat OutputFormatEnum
+	Parquet      ParquetWriterSettings
+}
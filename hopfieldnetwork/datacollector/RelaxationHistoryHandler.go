@@ -1,30 +1,30 @@
 package datacollector
 
-import "github.com/xitongsys/parquet-go/writer"
-
 // StateIndex is the index of the state in the probe collection
 // StepIndex is the index of the states steps towards stability
 // State is the value of the state in this instance
 // EnergyProfile is the energy profile of the state in this instance
+// Overlaps are the overlaps of the state with each target state
+//
+// Depending on the relaxation history policy of the network, either State and EnergyProfile or Overlaps are recorded (the other is empty)
 type RelaxationHistoryData struct {
 	StateIndex    int       `parquet:"name=StateIndex, type=INT32"`
 	StepIndex     int       `parquet:"name=StepIndex, type=INT32"`
 	State         []float64 `parquet:"name=State, type=DOUBLE, repetitiontype=REPEATED"`
 	EnergyProfile []float64 `parquet:"name=EnergyProfile, type=DOUBLE, repetitiontype=REPEATED"`
+	Overlaps      []float64 `parquet:"name=Overlaps, type=DOUBLE, repetitiontype=REPEATED"`
+}
+
+func (data *RelaxationHistoryData) EventType() DataCollectionEventEnum {
+	return DataCollectionEvent_RelaxationHistory
 }
 
-func NewRelaxationHistoryData(dataFile string) *dataHandler {
-	fileHandle, dataWriter := newParquetWriter(dataFile, new(RelaxationHistoryData))
-	return &dataHandler{
-		eventID:     DataCollectionEvent_RelaxationHistory,
-		dataWriter:  dataWriter,
-		fileHandle:  fileHandle,
-		handleEvent: handleRelaxationHistoryEvent,
-		cleanupFn:   defaultCleanupFn,
-	}
+func (data *RelaxationHistoryData) isEvent() {}
+
+func NewRelaxationHistoryData(dataFile string, settings DataFileSettings) (DataHandler, error) {
+	return newDataFileHandler(dataFile, settings, new(RelaxationHistoryData), handleRelaxationHistoryEvent, defaultCleanupFn)
 }
 
-func handleRelaxationHistoryEvent(writer *writer.ParquetWriter, event interface{}) {
-	result := event.(RelaxationHistoryData)
-	writer.Write(result)
+func handleRelaxationHistoryEvent(dataWriter dataFileWriter, event *RelaxationHistoryData) error {
+	return dataWriter.Write(event)
 }
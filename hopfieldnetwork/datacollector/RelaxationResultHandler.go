@@ -1,9 +1,5 @@
 package datacollector
 
-import (
-	"github.com/xitongsys/parquet-go/writer"
-)
-
 // Representation of the result of a relaxation of a state.
 //
 // State is the state vector that has been relaxed.
@@ -11,27 +7,52 @@ import (
 // Stable is a bool representing if the state was stable when relaxation finished.
 // NumSteps is an int representing the number of steps taken when relaxation finished.
 // DistancesToTargets is an array of distances to all Targets states.
+// Overlaps are the overlaps (order parameters) of the final state with each target state.
+// NearestTargetIndex and MinimumDistance are the target state nearest the final state and the distance to it (-1 if there are no target states).
+// ExactRecall is true if the final state is the nearest target state (or its inverse, in which case InverseRecall is also true).
+// OriginTargetIndex is the target state the probe was generated from, InitialFlippedUnits the number of units of the probe that
+// differ from that target state, and FlippedUnits the number of units of the final state that differ from it (all -1 if the probe
+// was not generated from a target state).
+// Sweeps is the number of sweeps (updates of every unit) made, and UnitFlips the number of unit updates that changed a unit.
+// SweepEnergies is the energy of the state before relaxation and after each sweep, and EnergyDecrease the total decrease in energy.
+// EnergyIncreased is true if any sweep increased the energy of the state.
+// AttractorClass is the classification of the final state by its relation to the target states (see hopfieldnetwork.AttractorClassEnum).
+// MixtureTargets and MixtureSigns are the indices and signs of the target states composing the final state.
 type RelaxationResultData struct {
-	StateIndex         int       `parquet:"name=StateIndex, type=INT32"`
-	Stable             bool      `parquet:"name=Stable, type=BOOLEAN"`
-	NumSteps           int       `parquet:"name=NumSteps, type=INT32"`
-	FinalState         []float64 `parquet:"name=FinalState, type=DOUBLE, repetitiontype=REPEATED"`
-	DistancesToTargets []float64 `parquet:"name=DistancesToTargets, type=DOUBLE, repetitiontype=REPEATED"`
-	EnergyProfile      []float64 `parquet:"name=EnergyProfile, type=DOUBLE, repetitiontype=REPEATED"`
+	StateIndex          int       `parquet:"name=StateIndex, type=INT32"`
+	Stable              bool      `parquet:"name=Stable, type=BOOLEAN"`
+	NumSteps            int       `parquet:"name=NumSteps, type=INT32"`
+	FinalState          []float64 `parquet:"name=FinalState, type=DOUBLE, repetitiontype=REPEATED"`
+	DistancesToTargets  []float64 `parquet:"name=DistancesToTargets, type=DOUBLE, repetitiontype=REPEATED"`
+	EnergyProfile       []float64 `parquet:"name=EnergyProfile, type=DOUBLE, repetitiontype=REPEATED"`
+	Overlaps            []float64 `parquet:"name=Overlaps, type=DOUBLE, repetitiontype=REPEATED"`
+	NearestTargetIndex  int       `parquet:"name=NearestTargetIndex, type=INT32"`
+	MinimumDistance     float64   `parquet:"name=MinimumDistance, type=DOUBLE"`
+	ExactRecall         bool      `parquet:"name=ExactRecall, type=BOOLEAN"`
+	InverseRecall       bool      `parquet:"name=InverseRecall, type=BOOLEAN"`
+	OriginTargetIndex   int       `parquet:"name=OriginTargetIndex, type=INT32"`
+	InitialFlippedUnits int       `parquet:"name=InitialFlippedUnits, type=INT32"`
+	FlippedUnits        int       `parquet:"name=FlippedUnits, type=INT32"`
+	Sweeps              int       `parquet:"name=Sweeps, type=INT32"`
+	UnitFlips           int       `parquet:"name=UnitFlips, type=INT32"`
+	SweepEnergies       []float64 `parquet:"name=SweepEnergies, type=DOUBLE, repetitiontype=REPEATED"`
+	EnergyDecrease      float64   `parquet:"name=EnergyDecrease, type=DOUBLE"`
+	EnergyIncreased     bool      `parquet:"name=EnergyIncreased, type=BOOLEAN"`
+	AttractorClass      string    `parquet:"name=AttractorClass, type=BYTE_ARRAY, convertedtype=UTF8"`
+	MixtureTargets      []int     `parquet:"name=MixtureTargets, type=INT32, repetitiontype=REPEATED"`
+	MixtureSigns        []int     `parquet:"name=MixtureSigns, type=INT32, repetitiontype=REPEATED"`
+}
+
+func (data *RelaxationResultData) EventType() DataCollectionEventEnum {
+	return DataCollectionEvent_RelaxationResult
 }
 
-func NewRelaxationResultHandler(dataFile string) *dataHandler {
-	fileHandle, dataWriter := newParquetWriter(dataFile, new(RelaxationResultData))
-	return &dataHandler{
-		eventID:     DataCollectionEvent_RelaxationResult,
-		dataWriter:  dataWriter,
-		fileHandle:  fileHandle,
-		handleEvent: handleRelaxationResultEvent,
-		cleanupFn:   defaultCleanupFn,
-	}
+func (data *RelaxationResultData) isEvent() {}
+
+func NewRelaxationResultHandler(dataFile string, settings DataFileSettings) (DataHandler, error) {
+	return newDataFileHandler(dataFile, settings, new(RelaxationResultData), handleRelaxationResultEvent, defaultCleanupFn)
 }
 
-func handleRelaxationResultEvent(writer *writer.ParquetWriter, event interface{}) {
-	result := event.(RelaxationResultData)
-	writer.Write(result)
+func handleRelaxationResultEvent(dataWriter dataFileWriter, event *RelaxationResultData) error {
+	return dataWriter.Write(event)
 }
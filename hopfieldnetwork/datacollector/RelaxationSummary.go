@@ -0,0 +1,27 @@
+package datacollector
+
+// Representation of the convergence of all probe states of a trial, aggregated from the relaxation results
+// ProbeStates is the number of probe states relaxed
+// StableProbeStates is the number of probe states that relaxed to a stable state
+// ExactRecalls is the number of probe states that relaxed exactly onto a target state (or its inverse)
+// InverseRecalls is the number of probe states that relaxed exactly onto the inverse of a target state
+// MeanSweeps and MaximumSweeps are the mean and largest number of sweeps made relaxing a probe state
+// MeanUnitFlips is the mean number of unit updates that changed a unit, per probe state
+// MeanEnergyDecrease is the mean decrease in energy from probe state to final state
+// EnergyIncreasedProbes is the number of probe states with a sweep that increased the energy
+type RelaxationSummaryData struct {
+	ProbeStates           int     `parquet:"name=ProbeStates, type=INT32"`
+	StableProbeStates     int     `parquet:"name=StableProbeStates, type=INT32"`
+	ExactRecalls          int     `parquet:"name=ExactRecalls, type=INT32"`
+	InverseRecalls        int     `parquet:"name=InverseRecalls, type=INT32"`
+	MeanSweeps            float64 `parquet:"name=MeanSweeps, type=DOUBLE"`
+	MaximumSweeps         int     `parquet:"name=MaximumSweeps, type=INT32"`
+	MeanUnitFlips         float64 `parquet:"name=MeanUnitFlips, type=DOUBLE"`
+	MeanEnergyDecrease    float64 `parquet:"name=MeanEnergyDecrease, type=DOUBLE"`
+	EnergyIncreasedProbes int     `parquet:"name=EnergyIncreasedProbes, type=INT32"`
+}
+
+// Write the RelaxationSummary struct to the specified data file, with the given data file settings
+func WriteRelaxationSummary(dataFile string, settings DataFileSettings, summaryData *RelaxationSummaryData) error {
+	return writeDataRows(dataFile, settings, []*RelaxationSummaryData{summaryData})
+}
@@ -0,0 +1,54 @@
+package datacollector
+
+// Representation of a single eigenvalue (and singular value) of the learned weight matrix
+// EigenvalueIndex is the index of the eigenvalue, with eigenvalues in descending order (by real, then imaginary, part)
+// EigenvalueReal and EigenvalueImaginary are the real and imaginary parts of the eigenvalue. Eigenvalues of symmetric matrices are real
+// SingularValue is the singular value of the same index, with singular values in descending order
+type SpectrumData struct {
+	EigenvalueIndex     int     `parquet:"name=EigenvalueIndex, type=INT32"`
+	EigenvalueReal      float64 `parquet:"name=EigenvalueReal, type=DOUBLE"`
+	EigenvalueImaginary float64 `parquet:"name=EigenvalueImaginary, type=DOUBLE"`
+	SingularValue       float64 `parquet:"name=SingularValue, type=DOUBLE"`
+}
+
+// Representation of the summary of the spectrum of the learned weight matrix
+// Dimension is the dimension of the matrix
+// Symmetric is true if the matrix is symmetric, so the eigenvalues are real and the eigenvectors orthonormal
+// EffectiveRank is the exponential of the entropy of the normalized singular values
+// NumericalRank is the number of singular values that are not (numerically) zero
+// SpectralRadius is the largest magnitude of the eigenvalues
+type SpectralSummaryData struct {
+	Dimension      int     `parquet:"name=Dimension, type=INT32"`
+	Symmetric      bool    `parquet:"name=Symmetric, type=BOOLEAN"`
+	EffectiveRank  float64 `parquet:"name=EffectiveRank, type=DOUBLE"`
+	NumericalRank  int     `parquet:"name=NumericalRank, type=INT32"`
+	SpectralRadius float64 `parquet:"name=SpectralRadius, type=DOUBLE"`
+}
+
+// Representation of the projection of a target state onto the eigenvectors of the learned weight matrix
+// TargetIndex is the index of the target state
+// Alignments is the magnitude of the cosine between the target state and each eigenvector, in the order of the eigenvalues
+// MaximumAlignment is the largest alignment, and AlignedEigenvalueIndex the index of the eigenvalue of that eigenvector
+// RayleighQuotient is the quotient x^T W x / x^T x of the target state x
+type TargetProjectionData struct {
+	TargetIndex            int       `parquet:"name=TargetIndex, type=INT32"`
+	Alignments             []float64 `parquet:"name=Alignments, type=DOUBLE, repetitiontype=REPEATED"`
+	MaximumAlignment       float64   `parquet:"name=MaximumAlignment, type=DOUBLE"`
+	AlignedEigenvalueIndex int       `parquet:"name=AlignedEigenvalueIndex, type=INT32"`
+	RayleighQuotient       float64   `parquet:"name=RayleighQuotient, type=DOUBLE"`
+}
+
+// Write the spectrum of the learned weight matrix to the specified data file, in the given output format
+func WriteSpectrum(dataFile string, settings DataFileSettings, spectrumData []*SpectrumData) error {
+	return writeDataRows(dataFile, settings, spectrumData)
+}
+
+// Write the summary of the spectrum of the learned weight matrix to the specified data file, in the given output format
+func WriteSpectralSummary(dataFile string, settings DataFileSettings, summaryData *SpectralSummaryData) error {
+	return writeDataRows(dataFile, settings, []*SpectralSummaryData{summaryData})
+}
+
+// Write the projections of the target states onto the eigenvectors of the learned weight matrix to the specified data file, in the given output format
+func WriteTargetProjections(dataFile string, settings DataFileSettings, projectionData []*TargetProjectionData) error {
+	return writeDataRows(dataFile, settings, projectionData)
+}
@@ -0,0 +1,34 @@
+package datacollector
+
+// Representation of a single trial within a parameter sweep
+// TrialIndex is the index of the trial within the sweep
+// TrialDirectory is the directory (relative to the sweep directory) the trial data is stored in
+// Repeat is the repeat index of this trial for this combination of parameters
+// Seed is the seed used for the trial
+// NetworkDimension is the dimension of the network
+// TargetStates is the number of target states learned
+// LearningRule is the network learning rule (as a string)
+// LearningNoiseScale is the scale of the noise applied during learning
+// StableTargetStates is the number of target states that are stable after learning
+// ProbeStates is the number of probe states relaxed
+// StableProbeStates is the number of probe states that relaxed to a stable state
+// Error is the error the trial failed with, or empty if the trial succeeded
+type SweepIndexData struct {
+	TrialIndex         int     `parquet:"name=TrialIndex, type=INT32"`
+	TrialDirectory     string  `parquet:"name=TrialDirectory, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Repeat             int     `parquet:"name=Repeat, type=INT32"`
+	Seed               int64   `parquet:"name=Seed, type=INT64"`
+	NetworkDimension   int     `parquet:"name=NetworkDimension, type=INT32"`
+	TargetStates       int     `parquet:"name=TargetStates, type=INT32"`
+	LearningRule       string  `parquet:"name=LearningRule, type=BYTE_ARRAY, convertedtype=UTF8"`
+	LearningNoiseScale float64 `parquet:"name=LearningNoiseScale, type=DOUBLE"`
+	StableTargetStates int     `parquet:"name=StableTargetStates, type=INT32"`
+	ProbeStates        int     `parquet:"name=ProbeStates, type=INT32"`
+	StableProbeStates  int     `parquet:"name=StableProbeStates, type=INT32"`
+	Error              string  `parquet:"name=Error, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+// Write the index of a parameter sweep to the specified data file, in the given output format
+func WriteSweepIndex(dataFile string, settings DataFileSettings, indexData []*SweepIndexData) error {
+	return writeDataRows(dataFile, settings, indexData)
+}
@@ -1,7 +1,5 @@
 package datacollector
 
-import "github.com/xitongsys/parquet-go/writer"
-
 // TargetStateIndex is the index of the target state being probed
 // IsStable is a flag indicating if this target state is stable in the network
 // State is the state vector
@@ -13,18 +11,16 @@ type TargetStateProbeData struct {
 	EnergyProfile    []float64 `parquet:"name=EnergyProfile, type=DOUBLE, repetitiontype=REPEATED"`
 }
 
-func NewTargetStateProbeHandler(dataFile string) *dataHandler {
-	fileHandle, dataWriter := newParquetWriter(dataFile, new(TargetStateProbeData))
-	return &dataHandler{
-		eventID:     DataCollectionEvent_TargetStateProbe,
-		dataWriter:  dataWriter,
-		fileHandle:  fileHandle,
-		handleEvent: handleTargetStateProbeEvent,
-		cleanupFn:   defaultCleanupFn,
-	}
+func (data *TargetStateProbeData) EventType() DataCollectionEventEnum {
+	return DataCollectionEvent_TargetStateProbe
+}
+
+func (data *TargetStateProbeData) isEvent() {}
+
+func NewTargetStateProbeHandler(dataFile string, settings DataFileSettings) (DataHandler, error) {
+	return newDataFileHandler(dataFile, settings, new(TargetStateProbeData), handleTargetStateProbeEvent, defaultCleanupFn)
 }
 
-func handleTargetStateProbeEvent(writer *writer.ParquetWriter, event interface{}) {
-	result := event.(TargetStateProbeData)
-	writer.Write(result)
+func handleTargetStateProbeEvent(dataWriter dataFileWriter, event *TargetStateProbeData) error {
+	return dataWriter.Write(event)
 }
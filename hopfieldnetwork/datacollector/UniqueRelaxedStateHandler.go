@@ -1,50 +1,106 @@
 package datacollector
 
 import (
-	"fmt"
+	"encoding/binary"
+	"math"
 
-	"github.com/xitongsys/parquet-go/writer"
-)
+	"hmcalister/hopfield/hopfieldnetwork/domain"
 
-var (
-	uniqueRelaxedStatesArray = []*UniqueRelaxedStateData{}
-	uniqueRelaxedStatesMap   = map[string]*UniqueRelaxedStateData{}
+	"gonum.org/v1/gonum/mat"
 )
 
-// Representation of the result of a relaxation of a state.
+// Representation of a unique state that probe states relaxed to.
+//
+// States are identified up to global inversion, so a state and its inverse are the same unique state. Each unique state is
+// described by the first relaxation result that reached it (or its inverse), so the fields below are those of that result.
 //
-// State is the state vector that has been relaxed.
-// UnitEnergies is a vector representing the energies of each unit.
-// Stable is a bool representing if the state was stable when relaxation finished.
-// NumSteps is an int representing the number of steps taken when relaxation finished.
-// DistancesToTargets is an array of distances to all Targets states.
+// StateIndex is the index of the first probe state that relaxed to this state.
+// Stable is a bool representing if the state was stable when that relaxation finished.
+// FinalState is the unique state itself, as reached by that relaxation (which may be the inverse of a later relaxed state).
+// DistancesToTargets is an array of distances from this state to all target states.
+// EnergyProfile is the energy of each unit of this state.
+// Overlaps are the overlaps (order parameters) of this state with each target state.
+// NearestTargetIndex, MinimumDistance, ExactRecall and InverseRecall describe the target state nearest this state, see RelaxationResultData.
+// Hits is the number of relaxation results with a final state equal to this state or to its inverse, including the first.
+// AttractorClass is the classification of this state by its relation to the target states (see hopfieldnetwork.AttractorClassEnum).
+// MixtureTargets and MixtureSigns are the indices and signs of the target states composing this state.
 type UniqueRelaxedStateData struct {
 	StateIndex         int       `parquet:"name=StateIndex, type=INT32"`
 	Stable             bool      `parquet:"name=Stable, type=BOOLEAN"`
 	FinalState         []float64 `parquet:"name=FinalState, type=DOUBLE, repetitiontype=REPEATED"`
 	DistancesToTargets []float64 `parquet:"name=DistancesToTargets, type=DOUBLE, repetitiontype=REPEATED"`
 	EnergyProfile      []float64 `parquet:"name=EnergyProfile, type=DOUBLE, repetitiontype=REPEATED"`
+	Overlaps           []float64 `parquet:"name=Overlaps, type=DOUBLE, repetitiontype=REPEATED"`
+	NearestTargetIndex int       `parquet:"name=NearestTargetIndex, type=INT32"`
+	MinimumDistance    float64   `parquet:"name=MinimumDistance, type=DOUBLE"`
+	ExactRecall        bool      `parquet:"name=ExactRecall, type=BOOLEAN"`
+	InverseRecall      bool      `parquet:"name=InverseRecall, type=BOOLEAN"`
 	Hits               int       `parquet:"name=Hits, type=INT32"`
+	AttractorClass     string    `parquet:"name=AttractorClass, type=BYTE_ARRAY, convertedtype=UTF8"`
+	MixtureTargets     []int     `parquet:"name=MixtureTargets, type=INT32, repetitiontype=REPEATED"`
+	MixtureSigns       []int     `parquet:"name=MixtureSigns, type=INT32, repetitiontype=REPEATED"`
 }
 
-func NewUniqueRelaxedStateHandler(dataFile string) *dataHandler {
-	fileHandle, dataWriter := newParquetWriter(dataFile, new(UniqueRelaxedStateData))
-	return &dataHandler{
-		eventID:     DataCollectionEvent_RelaxationResult,
-		dataWriter:  dataWriter,
-		fileHandle:  fileHandle,
-		handleEvent: handleUniqueRelaxedState,
-		cleanupFn:   cleanupUniqueRelaxedState,
+// The unique relaxed states seen by a single handler, in the order first seen.
+type uniqueRelaxedStates struct {
+	domainManager domain.DomainManager
+	states        []*UniqueRelaxedStateData
+	stateKeys     map[string]*UniqueRelaxedStateData
+}
+
+// Create a handler collecting the unique final states of relaxation, written to the data file once the handler is closed.
+//
+// States are unique up to global inversion, so a state and its inverse are counted as the same state.
+//
+// # Arguments
+//
+// dataFile string: The path to the data file to write
+//
+// settings DataFileSettings: The format (and parquet settings) to write the data file with
+//
+// networkDomain domain.DomainEnum: The domain of the relaxed states, which determines the inverse of a state
+func NewUniqueRelaxedStateHandler(dataFile string, settings DataFileSettings, networkDomain domain.DomainEnum) (DataHandler, error) {
+	uniqueStates := &uniqueRelaxedStates{
+		domainManager: domain.GetDomainManager(networkDomain),
+		states:        []*UniqueRelaxedStateData{},
+		stateKeys:     map[string]*UniqueRelaxedStateData{},
 	}
+	return newDataFileHandler(dataFile, settings, new(UniqueRelaxedStateData), uniqueStates.handleRelaxationResult, uniqueStates.cleanup)
 }
 
-func handleUniqueRelaxedState(writer *writer.ParquetWriter, event interface{}) {
-	// Note result is coming from relaxation result, so we have to cast to that...
-	relaxationResult := event.(RelaxationResultData)
-	energyHash := fmt.Sprint(relaxationResult.EnergyProfile)
+// Get a key identifying a state up to global inversion.
+//
+// The key is the byte representation of whichever of the state and its inverse is lexicographically smaller.
+func (uniqueStates *uniqueRelaxedStates) stateKey(state []float64) string {
+	inverseState := mat.NewVecDense(len(state), nil)
+	inverseState.CopyVec(mat.NewVecDense(len(state), state))
+	uniqueStates.domainManager.InvertState(inverseState)
+
+	canonicalState := state
+	for unitIndex, unitValue := range state {
+		inverseValue := inverseState.AtVec(unitIndex)
+		if inverseValue < unitValue {
+			canonicalState = inverseState.RawVector().Data
+			break
+		}
+		if unitValue < inverseValue {
+			break
+		}
+	}
+
+	key := make([]byte, 8*len(canonicalState))
+	for unitIndex, unitValue := range canonicalState {
+		binary.LittleEndian.PutUint64(key[8*unitIndex:], math.Float64bits(unitValue))
+	}
+	return string(key)
+}
+
+// Note the event is a relaxation result, as unique states are found from the final states of relaxation
+func (uniqueStates *uniqueRelaxedStates) handleRelaxationResult(dataWriter dataFileWriter, relaxationResult *RelaxationResultData) error {
+	stateKey := uniqueStates.stateKey(relaxationResult.FinalState)
 
 	// See if state has been seen before
-	val, ok := uniqueRelaxedStatesMap[energyHash]
+	val, ok := uniqueStates.stateKeys[stateKey]
 	if !ok {
 		result := UniqueRelaxedStateData{
 			StateIndex:         relaxationResult.StateIndex,
@@ -52,22 +108,34 @@ func handleUniqueRelaxedState(writer *writer.ParquetWriter, event interface{}) {
 			FinalState:         relaxationResult.FinalState,
 			DistancesToTargets: relaxationResult.DistancesToTargets,
 			EnergyProfile:      relaxationResult.EnergyProfile,
+			Overlaps:           relaxationResult.Overlaps,
+			NearestTargetIndex: relaxationResult.NearestTargetIndex,
+			MinimumDistance:    relaxationResult.MinimumDistance,
+			ExactRecall:        relaxationResult.ExactRecall,
+			InverseRecall:      relaxationResult.InverseRecall,
 			Hits:               1,
+			AttractorClass:     relaxationResult.AttractorClass,
+			MixtureTargets:     relaxationResult.MixtureTargets,
+			MixtureSigns:       relaxationResult.MixtureSigns,
 		}
 
-		uniqueRelaxedStatesArray = append(uniqueRelaxedStatesArray, &result)
-		uniqueRelaxedStatesMap[energyHash] = &result
+		uniqueStates.states = append(uniqueStates.states, &result)
+		uniqueStates.stateKeys[stateKey] = &result
 
-		return
+		return nil
 	} else {
 		// The state HAS been seen before, so we simply increment hits
 		val.Hits += 1
 	}
+	return nil
 }
 
-func cleanupUniqueRelaxedState(writer *writer.ParquetWriter) {
+func (uniqueStates *uniqueRelaxedStates) cleanup(dataWriter dataFileWriter) error {
 	// Actually write all the structs we've stored
-	for _, data := range uniqueRelaxedStatesArray {
-		writer.Write(data)
+	for _, data := range uniqueStates.states {
+		if err := dataWriter.Write(data); err != nil {
+			return err
+		}
 	}
+	return nil
 }
@@ -1,14 +1,118 @@
 package distancemeasure
 
 import (
+	"fmt"
+
 	"hmcalister/hopfield/hopfieldnetwork/domain"
 	"hmcalister/hopfield/hopfieldutils"
 
 	"gonum.org/v1/gonum/mat"
 )
 
+// Define a distance measure as a function of two states, where a distance of zero means the states are equal.
 type DistanceMeasure func(*mat.VecDense, *mat.VecDense) float64
 
+// Define the different distance measure options.
+//
+// Each measure has a variant with inversion, taking the smaller of the distance to a state and the distance to
+// its inverse, so a state and its inverse are treated as equally close to every other state.
+type DistanceMeasureEnum int
+
+const (
+	// The sum of the absolute differences of the units (the L1 norm of the difference)
+	ManhattanDistance              DistanceMeasureEnum = iota
+	ManhattanDistanceWithInversion DistanceMeasureEnum = iota
+
+	// The L2 norm of the difference of the states
+	EuclideanDistance              DistanceMeasureEnum = iota
+	EuclideanDistanceWithInversion DistanceMeasureEnum = iota
+
+	// The number of units that differ between the states
+	HammingDistance              DistanceMeasureEnum = iota
+	HammingDistanceWithInversion DistanceMeasureEnum = iota
+
+	// One minus the overlap of the states (see Overlap), in the range [0, 2]
+	OverlapDistance              DistanceMeasureEnum = iota
+	OverlapDistanceWithInversion DistanceMeasureEnum = iota
+
+	// One minus the cosine similarity of the states, in the range [0, 2]
+	CosineDistance              DistanceMeasureEnum = iota
+	CosineDistanceWithInversion DistanceMeasureEnum = iota
+)
+
+// All valid DistanceMeasureEnum values, used when parsing distance measures from strings.
+var distanceMeasureEnumValues = []DistanceMeasureEnum{
+	ManhattanDistance,
+	ManhattanDistanceWithInversion,
+	EuclideanDistance,
+	EuclideanDistanceWithInversion,
+	HammingDistance,
+	HammingDistanceWithInversion,
+	OverlapDistance,
+	OverlapDistanceWithInversion,
+	CosineDistance,
+	CosineDistanceWithInversion,
+}
+
+// Parse a DistanceMeasureEnum from either its name (e.g. "HammingDistance", case insensitive) or its integer value.
+func ParseDistanceMeasureEnum(name string) (DistanceMeasureEnum, error) {
+	return hopfieldutils.ParseEnum(name, distanceMeasureEnumValues)
+}
+
+// Implement encoding.TextMarshaler so distance measures are written by name in configuration files.
+func (i DistanceMeasureEnum) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// Implement encoding.TextUnmarshaler so distance measures can be read by name from configuration files.
+func (i *DistanceMeasureEnum) UnmarshalText(text []byte) error {
+	parsedMeasure, err := ParseDistanceMeasureEnum(string(text))
+	if err != nil {
+		return err
+	}
+	*i = parsedMeasure
+	return nil
+}
+
+// Get a distance measure function given the enum, for states in the given domain.
+//
+// # Arguments
+//
+// distanceMeasure DistanceMeasureEnum: The distance measure to get
+//
+// networkDomain domain.DomainEnum: The domain of the measured states, which determines the inverse of a state (and the overlap)
+//
+// # Returns
+//
+// The distance measure function. Panics if the distance measure is unknown.
+func GetDistanceMeasure(distanceMeasure DistanceMeasureEnum, networkDomain domain.DomainEnum) DistanceMeasure {
+	manager := domain.GetDomainManager(networkDomain)
+	switch distanceMeasure {
+	case ManhattanDistance:
+		return GetManhattanDistance()
+	case ManhattanDistanceWithInversion:
+		return GetManhattanDistanceWithInversion(manager)
+	case EuclideanDistance:
+		return GetEuclideanDistance()
+	case EuclideanDistanceWithInversion:
+		return GetEuclideanDistanceWithInversion(manager)
+	case HammingDistance:
+		return GetHammingDistance()
+	case HammingDistanceWithInversion:
+		return withInversion(manager, GetHammingDistance())
+	case OverlapDistance:
+		return GetOverlapDistance(networkDomain)
+	case OverlapDistanceWithInversion:
+		return withInversion(manager, GetOverlapDistance(networkDomain))
+	case CosineDistance:
+		return GetCosineDistance()
+	case CosineDistanceWithInversion:
+		return withInversion(manager, GetCosineDistance())
+	default:
+		panic(fmt.Sprintf("unknown distance measure %v", distanceMeasure))
+	}
+}
+
 func MeasureDistancesToCollection(collection []*mat.VecDense, a *mat.VecDense, measure DistanceMeasure) []float64 {
 	distances := make([]float64, len(collection))
 	for collectionIndex, currentVector := range collection {
@@ -64,3 +168,41 @@ func GetEuclideanDistanceWithInversion(manager domain.DomainManager) DistanceMea
 		return hopfieldutils.MinimumOfSlice([]float64{d1, d2})
 	}
 }
+
+func GetHammingDistance() DistanceMeasure {
+	return func(a *mat.VecDense, b *mat.VecDense) float64 {
+		differingUnits := 0
+		for i := 0; i < a.Len(); i++ {
+			if a.AtVec(i) != b.AtVec(i) {
+				differingUnits += 1
+			}
+		}
+		return float64(differingUnits)
+	}
+}
+
+func GetOverlapDistance(networkDomain domain.DomainEnum) DistanceMeasure {
+	return func(a *mat.VecDense, b *mat.VecDense) float64 {
+		return 1 - Overlap(a, b, networkDomain)
+	}
+}
+
+// Note a state with no magnitude (e.g. the all zero binary state) has a cosine similarity of zero with every state
+func GetCosineDistance() DistanceMeasure {
+	return func(a *mat.VecDense, b *mat.VecDense) float64 {
+		normProduct := a.Norm(2.0) * b.Norm(2.0)
+		if normProduct == 0 {
+			return 1
+		}
+		return 1 - mat.Dot(a, b)/normProduct
+	}
+}
+
+// Wrap a distance measure to take the smaller of the distance from a and the distance from the inverse of a.
+func withInversion(manager domain.DomainManager, measure DistanceMeasure) DistanceMeasure {
+	return func(a *mat.VecDense, b *mat.VecDense) float64 {
+		aInverse := mat.VecDenseCopyOf(a)
+		manager.InvertState(aInverse)
+		return hopfieldutils.MinimumOfSlice([]float64{measure(a, b), measure(aInverse, b)})
+	}
+}
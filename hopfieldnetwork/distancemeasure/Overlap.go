@@ -0,0 +1,55 @@
+package distancemeasure
+
+import (
+	"hmcalister/hopfield/hopfieldnetwork/domain"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Get the overlap (order parameter) m = (1/N) a·b of two states of dimension N.
+//
+// States are compared in the bipolar domain, so binary units are mapped from {0, 1} to {-1, 1} first. The overlap of two
+// states in either domain is then 1 if the states are equal, -1 if one state is the inverse of the other, and near 0 if
+// the states are uncorrelated. Note this is a similarity, not a distance: larger overlaps mean more similar states.
+//
+// # Arguments
+//
+// a, b *mat.VecDense: The states to measure the overlap of
+//
+// networkDomain domain.DomainEnum: The domain of the states
+//
+// # Returns
+//
+// The overlap of the two states, in the range [-1, 1]
+func Overlap(a *mat.VecDense, b *mat.VecDense, networkDomain domain.DomainEnum) float64 {
+	if networkDomain != domain.BinaryDomain {
+		return mat.Dot(a, b) / float64(a.Len())
+	}
+
+	overlap := 0.0
+	for i := 0; i < a.Len(); i++ {
+		overlap += (2*a.AtVec(i) - 1) * (2*b.AtVec(i) - 1)
+	}
+	return overlap / float64(a.Len())
+}
+
+// Get the overlap of a state with every state in a collection, see Overlap.
+//
+// # Arguments
+//
+// collection []*mat.VecDense: The states to measure the overlap with, e.g. the target states of a network
+//
+// a *mat.VecDense: The state to measure the overlaps of
+//
+// networkDomain domain.DomainEnum: The domain of the states
+//
+// # Returns
+//
+// The overlap of the state with each state of the collection, in the order of the collection
+func MeasureOverlapsToCollection(collection []*mat.VecDense, a *mat.VecDense, networkDomain domain.DomainEnum) []float64 {
+	overlaps := make([]float64, len(collection))
+	for collectionIndex, currentVector := range collection {
+		overlaps[collectionIndex] = Overlap(currentVector, a, networkDomain)
+	}
+	return overlaps
+}
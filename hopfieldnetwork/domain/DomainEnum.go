@@ -1,5 +1,7 @@
 package domain
 
+import "hmcalister/hopfield/hopfieldutils"
+
 // An enum to note the domain of the network.
 type DomainEnum int
 
@@ -7,3 +9,29 @@ const (
 	BipolarDomain DomainEnum = iota
 	BinaryDomain  DomainEnum = iota
 )
+
+// All valid DomainEnum values, used when parsing domains from strings.
+var domainEnumValues = []DomainEnum{
+	BipolarDomain,
+	BinaryDomain,
+}
+
+// Parse a DomainEnum from either its name (e.g. "BipolarDomain", case insensitive) or its integer value.
+func ParseDomainEnum(name string) (DomainEnum, error) {
+	return hopfieldutils.ParseEnum(name, domainEnumValues)
+}
+
+// Implement encoding.TextMarshaler so domains are written by name in configuration files.
+func (i DomainEnum) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// Implement encoding.TextUnmarshaler so domains can be read by name from configuration files.
+func (i *DomainEnum) UnmarshalText(text []byte) error {
+	parsedDomain, err := ParseDomainEnum(string(text))
+	if err != nil {
+		return err
+	}
+	*i = parsedDomain
+	return nil
+}
@@ -35,6 +35,34 @@ const (
 	GaussianApplication NoiseApplicationEnum = iota
 )
 
+// All valid NoiseApplicationEnum values, used when parsing noise application methods from strings.
+var noiseApplicationEnumValues = []NoiseApplicationEnum{
+	None,
+	MaximalInversion,
+	RandomSubMaximalInversion,
+	GaussianApplication,
+}
+
+// Parse a NoiseApplicationEnum from either its name (e.g. "MaximalInversion", case insensitive) or its integer value.
+func ParseNoiseApplicationEnum(name string) (NoiseApplicationEnum, error) {
+	return hopfieldutils.ParseEnum(name, noiseApplicationEnumValues)
+}
+
+// Implement encoding.TextMarshaler so noise application methods are written by name in configuration files.
+func (i NoiseApplicationEnum) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// Implement encoding.TextUnmarshaler so noise application methods can be read by name from configuration files.
+func (i *NoiseApplicationEnum) UnmarshalText(text []byte) error {
+	parsedMethod, err := ParseNoiseApplicationEnum(string(text))
+	if err != nil {
+		return err
+	}
+	*i = parsedMethod
+	return nil
+}
+
 // Get a noise application function given an integer input
 func GetNoiseApplicationMethod(noiseApplication NoiseApplicationEnum) NoiseApplicationMethod {
 	noiseApplicationFunctions := map[NoiseApplicationEnum]NoiseApplicationMethod{
@@ -0,0 +1,203 @@
+// Functions to analyze the spectrum of a weight matrix, and the alignment of the eigenvectors with the target states.
+package spectralanalysis
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// The tolerance (relative to the largest element) within which a matrix is treated as symmetric.
+const SYMMETRY_TOLERANCE = 1e-12
+
+// The tolerance (relative to the largest singular value) below which a singular value is treated as zero when finding the numerical rank.
+const RANK_TOLERANCE = 1e-10
+
+// The spectrum of a weight matrix.
+//
+// Symmetric matrices are decomposed with mat.EigenSym, so all eigenvalues are real and the eigenvectors are orthonormal.
+// Asymmetric matrices are decomposed with mat.Eigen, so eigenvalues (and eigenvectors) may be complex and the eigenvectors
+// are only normalized. In both cases the eigenvalues are in descending order (by real, then imaginary, part).
+//
+// The singular values are in descending order. The effective rank is the exponential of the Shannon entropy of the
+// singular values normalized to sum to one (Roy and Vetterli), which is the dimension for a matrix with equal singular values
+// and 1 for a matrix of rank 1. The numerical rank is the number of singular values larger than RANK_TOLERANCE times
+// the largest singular value.
+type Spectrum struct {
+	Symmetric      bool
+	Eigenvalues    []complex128
+	Eigenvectors   *mat.CDense
+	SingularValues []float64
+	EffectiveRank  float64
+	NumericalRank  int
+}
+
+// The alignment of a single target state with the eigenvectors of a weight matrix.
+//
+// Alignments holds the magnitude of the cosine between the target state and each eigenvector, in the order of the eigenvalues.
+// For a symmetric matrix the squared alignments sum to one. MaximumAlignment is the largest alignment, and AlignedEigenvalueIndex
+// the index of the eigenvalue of that eigenvector. RayleighQuotient is the quotient x^T W x / x^T x of the target state x.
+type TargetProjection struct {
+	Alignments             []float64
+	MaximumAlignment       float64
+	AlignedEigenvalueIndex int
+	RayleighQuotient       float64
+}
+
+// Compute the spectrum of a square weight matrix.
+//
+// # Arguments
+//
+// matrix *mat.Dense: The (square) matrix to decompose
+//
+// # Returns
+//
+// The spectrum of the matrix, or an error if a decomposition fails
+func ComputeSpectrum(matrix *mat.Dense) (*Spectrum, error) {
+	dimension, _ := matrix.Dims()
+	spectrum := &Spectrum{
+		Symmetric:    isSymmetric(matrix),
+		Eigenvalues:  make([]complex128, dimension),
+		Eigenvectors: mat.NewCDense(dimension, dimension, nil),
+	}
+
+	var eigenvectors *mat.CDense
+	if spectrum.Symmetric {
+		symmetricMatrix := mat.NewSymDense(dimension, nil)
+		for i := 0; i < dimension; i++ {
+			for j := i; j < dimension; j++ {
+				symmetricMatrix.SetSym(i, j, matrix.At(i, j))
+			}
+		}
+		var eigenDecomposition mat.EigenSym
+		if !eigenDecomposition.Factorize(symmetricMatrix, true) {
+			return nil, errors.New("symmetric eigendecomposition failed")
+		}
+		var realEigenvectors mat.Dense
+		eigenDecomposition.VectorsTo(&realEigenvectors)
+		eigenvectors = mat.NewCDense(dimension, dimension, nil)
+		for i := 0; i < dimension; i++ {
+			for j := 0; j < dimension; j++ {
+				eigenvectors.Set(i, j, complex(realEigenvectors.At(i, j), 0))
+			}
+		}
+		for eigenvalueIndex, eigenvalue := range eigenDecomposition.Values(nil) {
+			spectrum.Eigenvalues[eigenvalueIndex] = complex(eigenvalue, 0)
+		}
+	} else {
+		var eigenDecomposition mat.Eigen
+		if !eigenDecomposition.Factorize(matrix, mat.EigenRight) {
+			return nil, errors.New("eigendecomposition failed")
+		}
+		eigenvectors = &mat.CDense{}
+		eigenDecomposition.VectorsTo(eigenvectors)
+		eigenDecomposition.Values(spectrum.Eigenvalues)
+	}
+
+	// Order the eigenvalues (and eigenvectors) in descending order
+	order := make([]int, dimension)
+	for i := range order {
+		order[i] = i
+	}
+	eigenvalues := append([]complex128{}, spectrum.Eigenvalues...)
+	sort.SliceStable(order, func(i, j int) bool {
+		if real(eigenvalues[order[i]]) != real(eigenvalues[order[j]]) {
+			return real(eigenvalues[order[i]]) > real(eigenvalues[order[j]])
+		}
+		return imag(eigenvalues[order[i]]) > imag(eigenvalues[order[j]])
+	})
+	for eigenvalueIndex, originalIndex := range order {
+		spectrum.Eigenvalues[eigenvalueIndex] = eigenvalues[originalIndex]
+		for i := 0; i < dimension; i++ {
+			spectrum.Eigenvectors.Set(i, eigenvalueIndex, eigenvectors.At(i, originalIndex))
+		}
+	}
+
+	var singularValueDecomposition mat.SVD
+	if !singularValueDecomposition.Factorize(matrix, mat.SVDNone) {
+		return nil, errors.New("singular value decomposition failed")
+	}
+	spectrum.SingularValues = singularValueDecomposition.Values(nil)
+	spectrum.NumericalRank = singularValueDecomposition.Rank(RANK_TOLERANCE)
+	spectrum.EffectiveRank = effectiveRank(spectrum.SingularValues)
+	return spectrum, nil
+}
+
+// Get the largest magnitude of the eigenvalues of the spectrum.
+func (spectrum *Spectrum) SpectralRadius() float64 {
+	spectralRadius := 0.0
+	for _, eigenvalue := range spectrum.Eigenvalues {
+		spectralRadius = math.Max(spectralRadius, cmplx.Abs(eigenvalue))
+	}
+	return spectralRadius
+}
+
+// Project a target state onto the eigenvectors of the spectrum of a matrix.
+//
+// # Arguments
+//
+// matrix *mat.Dense: The matrix the spectrum was computed from
+//
+// targetState *mat.VecDense: The target state to project
+//
+// # Returns
+//
+// The projection of the target state. A zero target state has no alignment with any eigenvector.
+func (spectrum *Spectrum) ProjectTargetState(matrix *mat.Dense, targetState *mat.VecDense) *TargetProjection {
+	dimension := targetState.Len()
+	projection := &TargetProjection{
+		Alignments: make([]float64, len(spectrum.Eigenvalues)),
+	}
+	targetNorm := mat.Norm(targetState, 2)
+	if targetNorm == 0 {
+		return projection
+	}
+
+	for eigenvalueIndex := range spectrum.Eigenvalues {
+		// The complex inner product of the eigenvector with the target state, using the conjugate of the eigenvector
+		innerProduct := complex(0, 0)
+		eigenvectorNorm := 0.0
+		for i := 0; i < dimension; i++ {
+			eigenvectorElement := spectrum.Eigenvectors.At(i, eigenvalueIndex)
+			innerProduct += cmplx.Conj(eigenvectorElement) * complex(targetState.AtVec(i), 0)
+			eigenvectorNorm += real(eigenvectorElement * cmplx.Conj(eigenvectorElement))
+		}
+		alignment := cmplx.Abs(innerProduct) / (math.Sqrt(eigenvectorNorm) * targetNorm)
+		projection.Alignments[eigenvalueIndex] = alignment
+		if alignment > projection.MaximumAlignment {
+			projection.MaximumAlignment = alignment
+			projection.AlignedEigenvalueIndex = eigenvalueIndex
+		}
+	}
+
+	projection.RayleighQuotient = mat.Inner(targetState, matrix, targetState) / (targetNorm * targetNorm)
+	return projection
+}
+
+// Determine if a matrix is symmetric, within SYMMETRY_TOLERANCE relative to the largest element.
+func isSymmetric(matrix *mat.Dense) bool {
+	tolerance := SYMMETRY_TOLERANCE * math.Max(math.Abs(mat.Max(matrix)), math.Abs(mat.Min(matrix)))
+	return mat.EqualApprox(matrix, matrix.T(), tolerance)
+}
+
+// Compute the effective rank of a matrix from its singular values, the exponential of the entropy of the normalized singular values.
+func effectiveRank(singularValues []float64) float64 {
+	singularValueSum := 0.0
+	for _, singularValue := range singularValues {
+		singularValueSum += singularValue
+	}
+	if singularValueSum == 0 {
+		return 0
+	}
+	entropy := 0.0
+	for _, singularValue := range singularValues {
+		if singularValue > 0 {
+			p := singularValue / singularValueSum
+			entropy -= p * math.Log(p)
+		}
+	}
+	return math.Exp(entropy)
+}
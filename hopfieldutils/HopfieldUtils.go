@@ -1,6 +1,10 @@
 package hopfieldutils
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"golang.org/x/exp/constraints"
 	"golang.org/x/exp/rand"
 )
@@ -104,3 +108,36 @@ func ChunkSlice[T any](slice []T, chunkSize int) [][]T {
 
 	return chunkedSlices
 }
+
+// Parse an enum value from a string.
+//
+// The string may either be the name of the enum value (as given by the String method, matched case insensitively)
+// or the integer value of the enum, which allows integer coded command line flags to continue to work.
+//
+// # Arguments
+//
+// name: The string to parse
+//
+// values: All valid values of the enum
+//
+// # Returns
+//
+// (The matching enum value, nil) on success, (zero value, error listing the valid options) on failure
+func ParseEnum[T interface {
+	~int
+	fmt.Stringer
+}](name string, values []T) (T, error) {
+	trimmedName := strings.TrimSpace(name)
+	for _, value := range values {
+		if strings.EqualFold(trimmedName, value.String()) || trimmedName == strconv.Itoa(int(value)) {
+			return value, nil
+		}
+	}
+
+	validNames := make([]string, len(values))
+	for i, value := range values {
+		validNames[i] = fmt.Sprintf("%v (%d)", value, int(value))
+	}
+	var zeroValue T
+	return zeroValue, fmt.Errorf("unknown %T %q, valid options are: %v", zeroValue, name, strings.Join(validNames, ", "))
+}
@@ -0,0 +1,251 @@
+// A package to read and write gonum matrices and vector collections in the NumPy .npy and .npz formats.
+//
+// The .npy format is documented at https://numpy.org/doc/stable/reference/generated/numpy.lib.format.html
+// Matrices are always written as little endian float64 ('<f8') arrays in C (row major) order.
+// Reading supports the common numeric dtypes, which are converted to float64 on load.
+package npyio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	npyMagicString = "\x93NUMPY"
+
+	// The total header length (including magic string and version) is padded to a multiple of this value
+	npyHeaderAlignment = 64
+)
+
+var (
+	descrRegex        = regexp.MustCompile(`'descr'\s*:\s*'([^']*)'`)
+	fortranOrderRegex = regexp.MustCompile(`'fortran_order'\s*:\s*(True|False)`)
+	shapeRegex        = regexp.MustCompile(`'shape'\s*:\s*\(([^)]*)\)`)
+)
+
+// The information held in a .npy header
+type npyHeader struct {
+	descr        string
+	fortranOrder bool
+	shape        []int
+}
+
+// Write a matrix to a writer in the .npy format.
+//
+// The matrix is written as a two dimensional '<f8' array in C order.
+//
+// # Arguments
+//
+// w io.Writer: The writer to write the array to
+//
+// matrix mat.Matrix: The matrix to write
+//
+// # Returns
+//
+// Error if something went wrong, nil if written correctly
+func writeNpy(w io.Writer, matrix mat.Matrix) error {
+	rows, cols := matrix.Dims()
+
+	headerDict := fmt.Sprintf("{'descr': '<f8', 'fortran_order': False, 'shape': (%d, %d), }", rows, cols)
+	// Magic string (6 bytes), version (2 bytes), header length (2 bytes), header dict, padding, newline
+	headerLength := len(headerDict) + 1
+	preambleLength := len(npyMagicString) + 4
+	if remainder := (preambleLength + headerLength) % npyHeaderAlignment; remainder != 0 {
+		headerLength += npyHeaderAlignment - remainder
+	}
+	if headerLength > math.MaxUint16 {
+		return errors.New("npy header is too long")
+	}
+	header := headerDict + strings.Repeat(" ", headerLength-len(headerDict)-1) + "\n"
+
+	buffer := bytes.Buffer{}
+	buffer.WriteString(npyMagicString)
+	buffer.Write([]byte{1, 0})
+	binary.Write(&buffer, binary.LittleEndian, uint16(headerLength))
+	buffer.WriteString(header)
+	if _, err := w.Write(buffer.Bytes()); err != nil {
+		return err
+	}
+
+	rowData := make([]byte, 8*cols)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			binary.LittleEndian.PutUint64(rowData[8*j:], math.Float64bits(matrix.At(i, j)))
+		}
+		if _, err := w.Write(rowData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read a .npy array from a reader into a matrix.
+//
+// Zero dimensional arrays are read as a 1x1 matrix, and one dimensional arrays are read as a single row.
+// Arrays with more than two dimensions are rejected.
+//
+// # Arguments
+//
+// r io.Reader: The reader to read the array from
+//
+// # Returns
+//
+// (loaded matrix, nil) on success, (nil, error) on errors
+func readNpy(r io.Reader) (*mat.Dense, error) {
+	header, err := readNpyHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows, cols int
+	switch len(header.shape) {
+	case 0:
+		rows, cols = 1, 1
+	case 1:
+		rows, cols = 1, header.shape[0]
+	case 2:
+		rows, cols = header.shape[0], header.shape[1]
+	default:
+		return nil, fmt.Errorf("npy array has shape %v, only arrays of dimension at most two are supported", header.shape)
+	}
+	if rows == 0 || cols == 0 {
+		return nil, fmt.Errorf("npy array has shape %v, cannot load an empty array", header.shape)
+	}
+
+	decode, itemSize, err := getElementDecoder(header.descr)
+	if err != nil {
+		return nil, err
+	}
+
+	rawData := make([]byte, rows*cols*itemSize)
+	if _, err := io.ReadFull(r, rawData); err != nil {
+		return nil, fmt.Errorf("could not read npy array data: %w", err)
+	}
+
+	data := make([]float64, rows*cols)
+	for i := range data {
+		data[i] = decode(rawData[i*itemSize : (i+1)*itemSize])
+	}
+
+	if header.fortranOrder {
+		// Fortran order is column major, so we read the transpose and copy it back into row major order
+		transposed := mat.NewDense(cols, rows, data)
+		return mat.DenseCopyOf(transposed.T()), nil
+	}
+	return mat.NewDense(rows, cols, data), nil
+}
+
+// Read and parse the header of a .npy array, leaving the reader at the start of the array data.
+func readNpyHeader(r io.Reader) (*npyHeader, error) {
+	preamble := make([]byte, len(npyMagicString)+2)
+	if _, err := io.ReadFull(r, preamble); err != nil {
+		return nil, fmt.Errorf("could not read npy preamble: %w", err)
+	}
+	if string(preamble[:len(npyMagicString)]) != npyMagicString {
+		return nil, errors.New("file is not in the npy format (magic string not found)")
+	}
+
+	var headerLength int
+	majorVersion := preamble[len(npyMagicString)]
+	switch majorVersion {
+	case 1:
+		var length uint16
+		if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+			return nil, fmt.Errorf("could not read npy header length: %w", err)
+		}
+		headerLength = int(length)
+	case 2, 3:
+		var length uint32
+		if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+			return nil, fmt.Errorf("could not read npy header length: %w", err)
+		}
+		headerLength = int(length)
+	default:
+		return nil, fmt.Errorf("unsupported npy format version %d", majorVersion)
+	}
+
+	headerBytes := make([]byte, headerLength)
+	if _, err := io.ReadFull(r, headerBytes); err != nil {
+		return nil, fmt.Errorf("could not read npy header: %w", err)
+	}
+	return parseNpyHeader(string(headerBytes))
+}
+
+// Parse the python dictionary literal that makes up a .npy header
+func parseNpyHeader(header string) (*npyHeader, error) {
+	descrMatch := descrRegex.FindStringSubmatch(header)
+	fortranMatch := fortranOrderRegex.FindStringSubmatch(header)
+	shapeMatch := shapeRegex.FindStringSubmatch(header)
+	if descrMatch == nil || fortranMatch == nil || shapeMatch == nil {
+		return nil, fmt.Errorf("malformed npy header %q", strings.TrimSpace(header))
+	}
+
+	shape := []int{}
+	for _, dimensionString := range strings.Split(shapeMatch[1], ",") {
+		dimensionString = strings.TrimSpace(dimensionString)
+		if dimensionString == "" {
+			continue
+		}
+		dimension, err := strconv.Atoi(strings.TrimSuffix(dimensionString, "L"))
+		if err != nil {
+			return nil, fmt.Errorf("malformed npy shape %q", shapeMatch[1])
+		}
+		shape = append(shape, dimension)
+	}
+
+	return &npyHeader{
+		descr:        descrMatch[1],
+		fortranOrder: fortranMatch[1] == "True",
+		shape:        shape,
+	}, nil
+}
+
+// Get a function to decode a single array element of the given dtype into a float64, along with the size of that element in bytes.
+func getElementDecoder(descr string) (func([]byte) float64, int, error) {
+	if len(descr) < 3 {
+		return nil, 0, fmt.Errorf("unsupported npy dtype %q", descr)
+	}
+
+	var byteOrder binary.ByteOrder
+	switch descr[0] {
+	case '<', '|', '=':
+		byteOrder = binary.LittleEndian
+	case '>':
+		byteOrder = binary.BigEndian
+	default:
+		return nil, 0, fmt.Errorf("unsupported npy dtype %q", descr)
+	}
+
+	switch descr[1:] {
+	case "f8":
+		return func(b []byte) float64 { return math.Float64frombits(byteOrder.Uint64(b)) }, 8, nil
+	case "f4":
+		return func(b []byte) float64 { return float64(math.Float32frombits(byteOrder.Uint32(b))) }, 4, nil
+	case "i8":
+		return func(b []byte) float64 { return float64(int64(byteOrder.Uint64(b))) }, 8, nil
+	case "i4":
+		return func(b []byte) float64 { return float64(int32(byteOrder.Uint32(b))) }, 4, nil
+	case "i2":
+		return func(b []byte) float64 { return float64(int16(byteOrder.Uint16(b))) }, 2, nil
+	case "i1":
+		return func(b []byte) float64 { return float64(int8(b[0])) }, 1, nil
+	case "u8":
+		return func(b []byte) float64 { return float64(byteOrder.Uint64(b)) }, 8, nil
+	case "u4":
+		return func(b []byte) float64 { return float64(byteOrder.Uint32(b)) }, 4, nil
+	case "u2":
+		return func(b []byte) float64 { return float64(byteOrder.Uint16(b)) }, 2, nil
+	case "u1", "b1":
+		return func(b []byte) float64 { return float64(b[0]) }, 1, nil
+	}
+	return nil, 0, fmt.Errorf("unsupported npy dtype %q", descr)
+}
@@ -0,0 +1,282 @@
+package npyio
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	NPY_FILE_EXTENSION = ".npy"
+	NPZ_FILE_EXTENSION = ".npz"
+)
+
+// Save a matrix to the given file in the .npy format.
+//
+// If the file does not exist, attempts to create it.
+// Warning: this method will overwrite the file it is pointed at!
+//
+// # Arguments
+//
+// matrix *mat.Dense: The matrix to save
+//
+// savepath string, Pathlike: The path to the file to save into
+//
+// # Returns
+//
+// Error if something went wrong, nil if saved correctly
+func SaveMatrix(matrix *mat.Dense, savepath string) error {
+	f, err := os.OpenFile(savepath, os.O_TRUNC|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	if err := writeNpy(f, matrix); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Loads a matrix from a saved .npy file
+//
+// One dimensional arrays are loaded as a matrix with a single row.
+//
+// # Arguments
+//
+// savepath string, Pathlike: The path to the file the matrix is saved in
+//
+// # Returns
+//
+// (loaded matrix, nil) on success, (nil, error) on errors
+func LoadMatrix(savepath string) (*mat.Dense, error) {
+	f, err := os.Open(savepath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	matrix, err := readNpy(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", savepath, err)
+	}
+	return matrix, nil
+}
+
+// Loads the shape of the array in a saved .npy file, reading only the header of the file.
+//
+// # Arguments
+//
+// savepath string, Pathlike: The path to the .npy file
+//
+// # Returns
+//
+// (shape of the array, nil) on success, (nil, error) on errors. A zero dimensional array has an empty shape.
+func LoadShape(savepath string) ([]int, error) {
+	f, err := os.Open(savepath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	header, err := readNpyHeader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", savepath, err)
+	}
+	return header.shape, nil
+}
+
+// Save a vector collection to the given file in the .npy format.
+//
+// If the file does not exist, attempts to create it.
+// Warning: this method will overwrite the file it is pointed at!
+//
+// Each vector is saved as a row of a two dimensional array, so numpy will load an array of shape (len(vecCollection), vectorLength).
+// Be aware this requires all vectors in the collection to have the same length!
+//
+// # Arguments
+//
+// vecCollection []*mat.VecDense: The vector collection to save
+//
+// savepath string, Pathlike: The path to the file to save into
+//
+// # Returns
+//
+// Error if something went wrong, nil if saved correctly
+func SaveVectorCollection(vecCollection []*mat.VecDense, savepath string) error {
+	matrix, err := VectorCollectionToMatrix(vecCollection)
+	if err != nil {
+		return err
+	}
+	return SaveMatrix(matrix, savepath)
+}
+
+// Loads a vector collection from a saved .npy file
+//
+// Each row of the saved array is loaded as a separate vector.
+//
+// # Arguments
+//
+// savepath string, Pathlike: The path to the file the vector collection is saved in
+//
+// # Returns
+//
+// (loaded vector collection, nil) on success, (nil, error) on errors
+func LoadVectorCollection(savepath string) ([]*mat.VecDense, error) {
+	matrix, err := LoadMatrix(savepath)
+	if err != nil {
+		return nil, err
+	}
+	return MatrixToVectorCollection(matrix), nil
+}
+
+// Save a collection of named matrices to the given file in the .npz format.
+//
+// Each matrix is saved as a separate .npy array in the (uncompressed) zip archive, so
+// numpy.load(savepath)[name] will load the matrix saved under name.
+//
+// If the file does not exist, attempts to create it.
+// Warning: this method will overwrite the file it is pointed at!
+//
+// # Arguments
+//
+// arrays map[string]*mat.Dense: The matrices to save, keyed by array name
+//
+// savepath string, Pathlike: The path to the file to save into
+//
+// # Returns
+//
+// Error if something went wrong, nil if saved correctly
+func SaveArchive(arrays map[string]*mat.Dense, savepath string) error {
+	if len(arrays) == 0 {
+		return errors.New("array collection is empty")
+	}
+
+	f, err := os.OpenFile(savepath, os.O_TRUNC|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	// Sort the array names so archives are written deterministically
+	arrayNames := make([]string, 0, len(arrays))
+	for name := range arrays {
+		arrayNames = append(arrayNames, name)
+	}
+	sort.Strings(arrayNames)
+
+	archiveWriter := zip.NewWriter(f)
+	for _, name := range arrayNames {
+		entryWriter, err := archiveWriter.CreateHeader(&zip.FileHeader{
+			Name:   name + NPY_FILE_EXTENSION,
+			Method: zip.Store,
+		})
+		if err != nil {
+			f.Close()
+			return err
+		}
+		if err := writeNpy(entryWriter, arrays[name]); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	if err := archiveWriter.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Loads a collection of named matrices from a saved .npz file.
+//
+// Both compressed (numpy.savez_compressed) and uncompressed (numpy.savez) archives are supported.
+//
+// # Arguments
+//
+// savepath string, Pathlike: The path to the file the archive is saved in
+//
+// # Returns
+//
+// (loaded matrices keyed by array name, nil) on success, (nil, error) on errors
+func LoadArchive(savepath string) (map[string]*mat.Dense, error) {
+	archiveReader, err := zip.OpenReader(savepath)
+	if err != nil {
+		return nil, err
+	}
+	defer archiveReader.Close()
+
+	arrays := make(map[string]*mat.Dense)
+	for _, entry := range archiveReader.File {
+		if !strings.HasSuffix(entry.Name, NPY_FILE_EXTENSION) {
+			continue
+		}
+
+		entryReader, err := entry.Open()
+		if err != nil {
+			return nil, err
+		}
+		matrix, err := readNpy(entryReader)
+		entryReader.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%v[%v]: %w", savepath, entry.Name, err)
+		}
+		arrays[strings.TrimSuffix(entry.Name, NPY_FILE_EXTENSION)] = matrix
+	}
+
+	if len(arrays) == 0 {
+		return nil, fmt.Errorf("%v: archive contains no npy arrays", savepath)
+	}
+	return arrays, nil
+}
+
+// Organize a vector collection as the rows of a new matrix.
+//
+// Be aware this requires all vectors in the collection to have the same length!
+//
+// # Arguments
+//
+// vecCollection []*mat.VecDense: The vector collection to organize into a matrix
+//
+// # Returns
+//
+// (matrix with one row per vector, nil) on success, (nil, error) on errors
+func VectorCollectionToMatrix(vecCollection []*mat.VecDense) (*mat.Dense, error) {
+	if len(vecCollection) == 0 {
+		return nil, errors.New("vector collection is empty")
+	}
+
+	vecLength := vecCollection[0].Len()
+	matrix := mat.NewDense(len(vecCollection), vecLength, nil)
+	for rowIndex, vec := range vecCollection {
+		if vec.Len() != vecLength {
+			return nil, errors.New("vector collection contains vectors of mismatched length")
+		}
+		matrix.RowView(rowIndex).(*mat.VecDense).CopyVec(vec)
+	}
+	return matrix, nil
+}
+
+// Split a matrix into a vector collection, one vector for each row of the matrix.
+//
+// The vectors do not share memory with the matrix.
+//
+// # Arguments
+//
+// matrix *mat.Dense: The matrix to split
+//
+// # Returns
+//
+// A slice of vectors, one for each row of the matrix
+func MatrixToVectorCollection(matrix *mat.Dense) []*mat.VecDense {
+	rows, _ := matrix.Dims()
+	vecCollection := make([]*mat.VecDense, rows)
+	for rowIndex := range vecCollection {
+		vecCollection[rowIndex] = mat.VecDenseCopyOf(matrix.RowView(rowIndex))
+	}
+	return vecCollection
+}
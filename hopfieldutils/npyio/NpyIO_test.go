@@ -0,0 +1,135 @@
+package npyio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"path"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Build the bytes of a version 1.0 .npy file with the given header dictionary and raw array data.
+func buildNpy(headerDict string, data []byte) []byte {
+	headerLength := len(headerDict) + 1
+	if remainder := (len(npyMagicString) + 4 + headerLength) % npyHeaderAlignment; remainder != 0 {
+		headerLength += npyHeaderAlignment - remainder
+	}
+
+	buffer := bytes.Buffer{}
+	buffer.WriteString(npyMagicString)
+	buffer.Write([]byte{1, 0})
+	binary.Write(&buffer, binary.LittleEndian, uint16(headerLength))
+	buffer.WriteString(headerDict + strings.Repeat(" ", headerLength-len(headerDict)-1) + "\n")
+	buffer.Write(data)
+	return buffer.Bytes()
+}
+
+// Test that a matrix saved in the .npy format is written as an aligned '<f8' array and loads back unchanged.
+func TestMatrixRoundTrip(t *testing.T) {
+	savepath := path.Join(t.TempDir(), "matrix.npy")
+	matrix := mat.NewDense(2, 3, []float64{1, -2.5, 0, math.Inf(1), 1e-300, -7})
+	if err := SaveMatrix(matrix, savepath); err != nil {
+		t.Fatal(err)
+	}
+
+	var encoded bytes.Buffer
+	if err := writeNpy(&encoded, matrix); err != nil {
+		t.Fatal(err)
+	}
+	header, err := readNpyHeader(bytes.NewReader(encoded.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.descr != "<f8" || header.fortranOrder || len(header.shape) != 2 || header.shape[0] != 2 || header.shape[1] != 3 {
+		t.Errorf("written header parsed as %+v", header)
+	}
+	if dataLength := 8 * 2 * 3; (encoded.Len()-dataLength)%npyHeaderAlignment != 0 {
+		t.Errorf("header of length %v is not aligned to %v bytes", encoded.Len()-dataLength, npyHeaderAlignment)
+	}
+
+	loaded, err := LoadMatrix(savepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat.Equal(matrix, loaded) {
+		t.Errorf("loaded matrix %v, expected %v", mat.Formatted(loaded), mat.Formatted(matrix))
+	}
+}
+
+// Test that named matrices saved in the .npz format load back unchanged.
+func TestArchiveRoundTrip(t *testing.T) {
+	savepath := path.Join(t.TempDir(), "archive.npz")
+	arrays := map[string]*mat.Dense{
+		"matrix":       mat.NewDense(2, 2, []float64{0, 1, 1, 0}),
+		"targetStates": mat.NewDense(1, 4, []float64{1, -1, -1, 1}),
+	}
+	if err := SaveArchive(arrays, savepath); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadArchive(savepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != len(arrays) {
+		t.Fatalf("loaded %v arrays, expected %v", len(loaded), len(arrays))
+	}
+	for name, matrix := range arrays {
+		if !mat.Equal(matrix, loaded[name]) {
+			t.Errorf("loaded array %v as %v, expected %v", name, mat.Formatted(loaded[name]), mat.Formatted(matrix))
+		}
+	}
+}
+
+// Test that arrays of other dtypes, byte orders and memory orders are converted to float64 on load.
+func TestReadOtherDtypes(t *testing.T) {
+	expected := mat.NewDense(2, 3, []float64{1, 2, 3, -4, 5, -6})
+
+	// Big endian float32 in Fortran (column major) order
+	bigEndianData := make([]byte, 4*6)
+	for index, value := range []float32{1, -4, 2, 5, 3, -6} {
+		binary.BigEndian.PutUint32(bigEndianData[4*index:], math.Float32bits(value))
+	}
+	// Little endian int32 in C (row major) order
+	integerData := make([]byte, 4*6)
+	for index, value := range []int32{1, 2, 3, -4, 5, -6} {
+		binary.LittleEndian.PutUint32(integerData[4*index:], uint32(value))
+	}
+
+	testCases := map[string][]byte{
+		">f4": buildNpy("{'descr': '>f4', 'fortran_order': True, 'shape': (2, 3), }", bigEndianData),
+		"<i4": buildNpy("{'descr': '<i4', 'fortran_order': False, 'shape': (2, 3), }", integerData),
+	}
+	for descr, encoded := range testCases {
+		loaded, err := readNpy(bytes.NewReader(encoded))
+		if err != nil {
+			t.Errorf("%v: %v", descr, err)
+			continue
+		}
+		if !mat.Equal(expected, loaded) {
+			t.Errorf("%v: loaded %v, expected %v", descr, mat.Formatted(loaded), mat.Formatted(expected))
+		}
+	}
+}
+
+// Test that one dimensional arrays load as a single row, and unsupported dtypes are rejected.
+func TestReadOneDimensionalAndUnsupported(t *testing.T) {
+	data := make([]byte, 8*3)
+	for index, value := range []float64{1, -1, 1} {
+		binary.LittleEndian.PutUint64(data[8*index:], math.Float64bits(value))
+	}
+	loaded, err := readNpy(bytes.NewReader(buildNpy("{'descr': '<f8', 'fortran_order': False, 'shape': (3,), }", data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows, cols := loaded.Dims(); rows != 1 || cols != 3 {
+		t.Errorf("one dimensional array loaded with shape (%v, %v), expected (1, 3)", rows, cols)
+	}
+
+	if _, err := readNpy(bytes.NewReader(buildNpy("{'descr': '<c16', 'fortran_order': False, 'shape': (1,), }", make([]byte, 16)))); err == nil {
+		t.Error("complex dtype was loaded without error")
+	}
+}
@@ -2,286 +2,174 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"io"
 	"log"
 	"os"
-	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
 
 	"github.com/hmcalister/gonum-matrix-io/pkg/gonumio"
 	"github.com/pkg/profile"
-	"github.com/schollz/progressbar/v3"
 	"gonum.org/v1/gonum/mat"
 
-	"hmcalister/hopfield/hopfieldnetwork"
-	"hmcalister/hopfield/hopfieldnetwork/datacollector"
-	"hmcalister/hopfield/hopfieldnetwork/domain"
-	"hmcalister/hopfield/hopfieldnetwork/noiseapplication"
-	states "hmcalister/hopfield/hopfieldnetwork/states"
-	"hmcalister/hopfield/hopfieldutils"
+	"hmcalister/hopfield/hopfieldutils/npyio"
 )
 
-const (
-	LEARNED_MATRIX_BINARY_SAVE_FILE = "matrix.bin"
-	TARGET_STATES_BINARY_SAVE_FILE  = "targetStates.bin"
-)
-
-var (
-	// General network flags
-
-	forceSymmetric   = flag.Bool("forceSymmetric", true, "Force the weight matrix of the Hopfield network to be symmetric.")
-	randomMatrixInit = flag.Bool("randomMatrixInit", false, "Flag to randomly initialize the matrix to small random values (for asymmetric seed).")
-	networkDomainInt = flag.Int("domain", 0, "The network domain.\n0: Bipolar\n1: Binary")
-	networkDimension = flag.Int("dimension", 100, "The network dimension to simulate.")
-	unitsUpdated     = flag.Int("unitsUpdated", 1, "The number of units to update at each step.")
-
-	// Learning method and rule flags
-
-	learningMethodInt = flag.Int("learningMethod", 0, "The learning method to use.\n0: Full Set\n1: Iterative Batch")
-	learningRuleInt   = flag.Int("learningRule", 0, "The learning rule to use.\n0: Hebbian\n1: Bipolar Mapped Hebbian\n2: Delta\n3: Bipolar Mapped Delta\n4: Thermal Delta\n5: Bipolar Mapped Thermal Delta")
-	numEpochs         = flag.Int("epochs", 100, "The number of epochs to train for.")
-
-	// Target and Probe state flags
-
-	numTargetStates        = flag.Int("numTargetStates", 1, "The number of learned states.")
-	targetStatesBinaryFile = flag.String("targetStatesFile", "", "Path to the binary file containing the vector collection to use as target states. If present, this method overrides random generation using numTargetStates.")
-	numProbeStates         = flag.Int("numProbeStates", 1000, "The number of probe states to use for each trial.")
-	probeStatesBinaryFile  = flag.String("probeStatesFile", "", "Path to the binary file containing the vector collection to use as probe states. If present, this method overrides random generation using numProbeStates.")
-
-	// Learning noise flags
-
-	learningRate           = flag.Float64("learningRate", 1.0, "The learning rate of the network. Should be greater than 0.0.")
-	learningNoiseMethodInt = flag.Int("learningNoiseMethod", 0, "The method of applying noise to learned states. Noise scale is determined by the learningNoiseScale Flag.\n0: No Noise\n1: Maximal Inversion\n2: Random SubMaximal Inversion\n3: Gaussian Application")
-	learningNoiseScale     = flag.Float64("learningNoiseScale", 0.0, "The amount of noise to apply to target states during learning.")
-
-	// General program flags
-
-	numThreads                   = flag.Int("threads", 1, "The number of threads to use for relaxation.")
-	dataDirectory                = flag.String("dataDir", "data/hopfieldData", "The directory to store data files in. Warning: Removes contents of directory!")
-	logFilePath                  = flag.String("logFile", "logs/log.txt", "The file to write logs to.")
-	allowIntensiveDataCollection = flag.Bool("allowIntensiveDataCollection", false, "Flag to allow data collection for very intensive methods, such as relaxationHistory")
-	verbose                      = flag.Bool("verbose", false, "Verbose flag to print log messages to stdout.")
-	enableProfiling              = flag.Bool("profile", false, "Enable profiling during this trial.")
-
-	networkDomain       domain.DomainEnum
-	learningMethod      hopfieldnetwork.LearningMethodEnum
-	learningRule        hopfieldnetwork.LearningRuleEnum
-	learningNoiseMethod noiseapplication.NoiseApplicationEnum
-	collector           *datacollector.DataCollector
-	logger              *log.Logger
-)
-
-func init() {
-	// Parse the command line flags and do any mapping from ints (flag variable) to enum (hopfieldnetwork variable)
-	flag.Parse()
-	networkDomain = domain.DomainEnum(*networkDomainInt)
-	learningMethod = hopfieldnetwork.LearningMethodEnum(*learningMethodInt)
-	learningRule = hopfieldnetwork.LearningRuleEnum(*learningRuleInt)
-	learningNoiseMethod = noiseapplication.NoiseApplicationEnum(*learningNoiseMethodInt)
-
-	// Make the directories needed to save data of trials to (if needed)
-	os.MkdirAll("logs", 0700)
-	if *enableProfiling {
-		os.MkdirAll("profiles", 0700)
-	}
-
-	// Tries to open logging file, panics if not possible (since we can't log anything otherwise!)
-	logFile, err := os.Create(*logFilePath)
+// The version of this program, recorded in the manifest of each run.
+// This is independent of version control, so must be updated with each release.
+const PROGRAM_VERSION = "0.2.0"
+
+// The usage message printed for an unknown (or missing) subcommand.
+const USAGE = `Usage: hopfield [command] [flags]
+
+Commands:
+  run        Learn a network and probe it, writing all data (the default if no command is given)
+  train      Learn a network and save it, without probing
+  probe      Probe a network previously saved by train
+  analyze    Summarize the data files written by run or probe
+  inspect    Print the properties of a saved network
+  basin      Estimate the basin of attraction of each target state of a saved network
+  landscape  Enumerate the energy landscape of a small saved network
+  verify     Verify that relaxation never increases the energy of states of a saved network
+  sweep      Run a parameter sweep of many trials
+  capacity   Estimate the storage capacity of networks by bisecting over the number of target states
+  version    Print the program version
+
+Run "hopfield [command] -h" for the flags of a command.
+`
+
+// Create a logger that writes to the given file, and also to stdout if verbose is set.
+//
+// The directory of the log file is created if needed.
+func newLogger(logFilePath string, verbose bool) (*log.Logger, error) {
+	os.MkdirAll(filepath.Dir(logFilePath), 0700)
+	logFile, err := os.Create(logFilePath)
 	if err != nil {
-		panic("Could not open log file!")
+		return nil, err
 	}
 
 	// Handle verbose flag
 	// If set, we make logs point to file *and* stdout
 	var multiWriter io.Writer
-	if *verbose {
+	if verbose {
 		multiWriter = io.MultiWriter(os.Stdout, logFile)
 	} else {
 		multiWriter = io.MultiWriter(logFile)
 	}
-	logger = log.New(multiWriter, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
+	return log.New(multiWriter, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile), nil
+}
 
-	// Remove old data directory and recreate
-	logger.Printf("Creating data directory %#v\n", *dataDirectory)
-	if err := os.RemoveAll(*dataDirectory); err != nil {
-		panic(err)
+// Start profiling if requested, returning the function to stop profiling.
+func startProfiling(enableProfiling bool) func() {
+	if !enableProfiling {
+		return func() {}
 	}
-	os.MkdirAll(*dataDirectory, 0700)
+	os.MkdirAll("profiles", 0700)
+	return profile.Start(profile.ClockProfile, profile.ProfilePath("./profiles")).Stop
+}
 
-	// Set up data collector to handle events during this trial
-	logger.Printf("Creating data collector")
-	collector = datacollector.NewDataCollector().
-		AddHandler(datacollector.NewRelaxationResultHandler(path.Join(*dataDirectory, "relaxationResult.pq"))).
-		AddHandler(datacollector.NewTargetStateProbeHandler(path.Join(*dataDirectory, "targetStateProbe.pq"))).
-		AddHandler(datacollector.NewUniqueRelaxedStateHandler(path.Join(*dataDirectory, "uniqueStates.pq"))).
-		AddHandler(datacollector.NewLearnStateHandler(path.Join(*dataDirectory, "learnStateData.pq")))
-	// Only add these collectors if we want to collect intensive data. Avoids creating additional files and extra listeners.
-	if *allowIntensiveDataCollection {
-		collector.AddHandler(datacollector.NewRelaxationHistoryData(path.Join(*dataDirectory, "relaxationHistory.pq")))
+// Load a vector collection from a file, selecting the file format by extension.
+//
+// Files ending in .npy are loaded as numpy arrays (one vector per row), all other files
+// are assumed to be in the gonumio binary format.
+func loadVectorCollection(filePath string) ([]*mat.VecDense, error) {
+	if strings.ToLower(filepath.Ext(filePath)) == npyio.NPY_FILE_EXTENSION {
+		return npyio.LoadVectorCollection(filePath)
 	}
+	return gonumio.LoadVectorCollection(filePath)
 }
 
-// Main method for entry point
-func main() {
-	if *enableProfiling {
-		defer profile.Start(profile.ClockProfile, profile.ProfilePath("./profiles")).Stop()
+// Load a matrix from a file, selecting the file format by extension.
+//
+// Files ending in .npy are loaded as numpy arrays, all other files are assumed to be in the gonumio binary format.
+func loadMatrix(filePath string) (*mat.Dense, error) {
+	if strings.ToLower(filepath.Ext(filePath)) == npyio.NPY_FILE_EXTENSION {
+		return npyio.LoadMatrix(filePath)
 	}
-	go collector.CollectData()
-	var err error
-
-	network := hopfieldnetwork.NewHopfieldNetworkBuilder().
-		SetNetworkDomain(networkDomain).
-		SetNetworkDimension(*networkDimension).
-		SetRandMatrixInit(*randomMatrixInit).
-		SetForceSymmetric(*forceSymmetric).
-		SetNetworkLearningMethod(learningMethod).
-		SetNetworkLearningRule(learningRule).
-		SetEpochs(*numEpochs).
-		SetMaximumRelaxationIterations(100).
-		SetMaximumRelaxationUnstableUnits(0).
-		SetLearningRate(*learningRate).
-		SetLearningNoiseMethod(learningNoiseMethod).
-		SetLearningNoiseRatio(*learningNoiseScale).
-		SetUnitsUpdatedPerStep(*unitsUpdated).
-		SetDataCollector(collector).
-		SetLogger(logger).
-		SetAllowIntensiveDataCollection(*allowIntensiveDataCollection).
-		Build()
+	return gonumio.LoadMatrix(filePath)
+}
 
-	stateGenerator := states.NewStateGeneratorBuilder().
-		SetRandMin(-1).
-		SetRandMax(1).
-		SetGeneratorDomain(networkDomain).
-		SetGeneratorDimension(*networkDimension).
-		Build()
+// Run a single trial (learning and probing) from the given command line arguments.
+func runRunCommand(args []string) error {
+	runFlags := flag.NewFlagSet("run", flag.ExitOnError)
+	experimentFlags := addExperimentFlags(runFlags)
+	probingFlags := addProbingFlags(runFlags)
+	outputFlags := addOutputFlags(runFlags, "data/hopfieldData")
+	runFlags.Parse(args)
 
-	// LEARNING PHASE -----------------------------------------------------------------------------
-	logger.SetPrefix("Network Learning: ")
-	// Either load states from binary file or generate a random number of states, based on flags
+	config, err := experimentFlags.resolveConfig(probingFlags)
+	if err != nil {
+		return err
+	}
 
-	// The target states of this network
-	var targetStates []*mat.VecDense
+	defer startProfiling(*outputFlags.enableProfiling)()
 
-	if *targetStatesBinaryFile == "" {
-		// If we are not given a file to load, generate a random collection
-		targetStates = stateGenerator.CreateStateCollection(*numTargetStates)
-	} else {
-		// We have a file to load, do so
-		targetStates, err = gonumio.LoadVectorCollection(*targetStatesBinaryFile)
-		if err != nil {
-			log.Fatalf("ERROR: %v\nTARGET STATES LOADING FAILED", err)
-		}
-		// Manually set the numTargetStates variable
-		*numTargetStates = len(targetStates)
+	runDirectory, err := outputFlags.createRunDirectory()
+	if err != nil {
+		return err
 	}
-
-	// Actually learn the target states
-	learnStateData := network.LearnStates(targetStates)
-	for _, data := range learnStateData {
-		collector.EventChannel <- hopfieldutils.IndexedWrapper[interface{}]{
-			Index: datacollector.DataCollectionEvent_LearnState,
-			Data:  *data,
-		}
+	logger, err := outputFlags.newLogger(runDirectory)
+	if err != nil {
+		return fmt.Errorf("could not open log file: %w", err)
 	}
+	logger.Printf("Writing to run directory %#v\n", runDirectory)
 
-	// Save the weight matrix to the specified path.
-	gonumio.SaveMatrix(network.GetMatrix(), path.Join(*dataDirectory, LEARNED_MATRIX_BINARY_SAVE_FILE))
-	gonumio.SaveVectorCollection(targetStates, path.Join(*dataDirectory, TARGET_STATES_BINARY_SAVE_FILE))
-
-	// Analyze specifically the learned states and save those results too
-	for stateIndex := range targetStates {
-		logger.Printf("Analyzing Target State %v\n", stateIndex)
-		state := targetStates[stateIndex]
-		targetStateData := datacollector.TargetStateProbeData{
-			TargetStateIndex: stateIndex,
-			IsStable:         network.StateIsStable(state),
-			State:            targetStates[stateIndex].RawVector().Data,
-			EnergyProfile:    network.AllUnitEnergies(state),
-		}
-		collector.EventChannel <- hopfieldutils.IndexedWrapper[interface{}]{
-			Index: datacollector.DataCollectionEvent_TargetStateProbe,
-			Data:  targetStateData,
-		}
+	if _, err := runTrial(config, runDirectory, logger); err != nil {
+		return err
 	}
-
-	// PROBING PHASE ------------------------------------------------------------------------------
-	logger.SetPrefix("Network Probing: ")
-	// Create and relax a set of probe states
-
-	var probeStates []*mat.VecDense
-	if *probeStatesBinaryFile == "" {
-		probeStates = stateGenerator.CreateStateCollection(*numProbeStates)
-	} else {
-		probeStates, err = gonumio.LoadVectorCollection(*probeStatesBinaryFile)
-		if err != nil {
-			log.Fatalf("ERROR: %v\nPROBE STATES LOADING FAILED", err)
-		}
-		*numProbeStates = len(probeStates)
+	if err := writeManifest(runDirectory, "run", args, config); err != nil {
+		return fmt.Errorf("could not write manifest: %w", err)
 	}
 
-	relaxationResults := network.ConcurrentRelaxStates(probeStates, *numThreads)
-
-	// DATA PROCESSING ----------------------------------------------------------------------------
-	logger.SetPrefix("Data Processing: ")
-	bar := progressbar.Default(int64(len(relaxationResults)), "SAVING RELAXATION RESULTS")
-	for stateIndex, result := range relaxationResults {
-		bar.Add(1)
-		event := datacollector.RelaxationResultData{
-			StateIndex:         stateIndex,
-			Stable:             result.Stable,
-			NumSteps:           len(result.StateHistory),
-			FinalState:         result.StateHistory[len(result.StateHistory)-1].RawVector().Data,
-			DistancesToTargets: result.DistancesToTargets,
-			EnergyProfile:      result.EnergyHistory[len(result.EnergyHistory)-1],
-		}
-
-		collector.EventChannel <- hopfieldutils.IndexedWrapper[interface{}]{
-			Index: datacollector.DataCollectionEvent_RelaxationResult,
-			Data:  event,
-		}
-
-		if *allowIntensiveDataCollection {
-			for stepIndex, stateHistoryItem := range result.StateHistory {
-				historyEvent := datacollector.RelaxationHistoryData{
-					StateIndex:    stateIndex,
-					StepIndex:     stepIndex,
-					State:         stateHistoryItem.RawVector().Data,
-					EnergyProfile: result.EnergyHistory[stepIndex],
-				}
+	logger.Println("DONE")
+	return nil
+}
 
-				collector.EventChannel <- hopfieldutils.IndexedWrapper[interface{}]{
-					Index: datacollector.DataCollectionEvent_RelaxationHistory,
-					Data:  historyEvent,
-				}
-			}
-		}
+// Main method for entry point
+//
+// The first argument selects the command to run. If no command is given (or the first argument is a flag)
+// a single trial is run, for compatibility with older scripts.
+func main() {
+	command := "run"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command = args[0]
+		args = args[1:]
 	}
 
-	// CLEAN UP & FINISH --------------------------------------------------------------------------
-	logger.SetPrefix("Clean Up: ")
-
-	hopfieldNetworkSummary := network.GetNetworkSummary()
-	// Save to data directory a record of this trial
-	networkSummaryData := datacollector.HopfieldNetworkSummaryData{
-		NetworkDomain:               networkDomain.String(),
-		NetworkDimension:            hopfieldNetworkSummary.Dimension,
-		LearningRule:                learningRule.String(),
-		Epochs:                      hopfieldNetworkSummary.Epochs,
-		MaximumRelaxationIterations: hopfieldNetworkSummary.MaximumRelaxationIterations,
-		LearningRate:                *learningRate,
-		LearningNoiseMethod:         learningNoiseMethod.String(),
-		LearningNoiseScale:          hopfieldNetworkSummary.LearningNoiseScale,
-		UnitsUpdated:                hopfieldNetworkSummary.UnitsUpdatedPerStep,
-		ForceSymmetricWeightMatrix:  hopfieldNetworkSummary.ForceSymmetric,
-		Threads:                     *numThreads,
-		TargetStates:                *numTargetStates,
-		ProbeStates:                 *numProbeStates,
+	var err error
+	switch command {
+	case "run":
+		err = runRunCommand(args)
+	case "train":
+		err = runTrainCommand(args)
+	case "probe":
+		err = runProbeCommand(args)
+	case "analyze":
+		err = runAnalyzeCommand(args)
+	case "inspect":
+		err = runInspectCommand(args)
+	case "basin":
+		err = runBasinCommand(args)
+	case "landscape":
+		err = runLandscapeCommand(args)
+	case "verify":
+		err = runVerifyCommand(args)
+	case "sweep":
+		err = runSweepCommand(args)
+	case "capacity":
+		err = runCapacityCommand(args)
+	case "version":
+		fmt.Printf("hopfield %v (%v)\n", PROGRAM_VERSION, runtime.Version())
+	case "help":
+		fmt.Print(USAGE)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %#v\n\n%v", command, USAGE)
+		os.Exit(2)
 	}
-	datacollector.WriteHopfieldNetworkSummary(path.Join(*dataDirectory, "networkSummary.pq"), &networkSummaryData)
-
-	if err := collector.WriteStop(); err != nil {
-		logger.Fatalf("ERR: %#v\n", err)
+	if err != nil {
+		log.Fatalf("ERROR: %v", err)
 	}
-	logger.Printf("Data written successfully")
-
-	logger.Println("DONE")
 }